@@ -0,0 +1,138 @@
+// Package configtest builds configurations for tests from inline YAML, maps
+// and in-memory file systems, without reading the working directory or the
+// process environment.
+package configtest
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/zbum/mantyboot/configuration"
+	"github.com/zbum/mantyboot/errors"
+)
+
+const DefaultProfile = "test"
+
+// Fixture describes the configuration to build. Layers are applied in field
+// order: FS, YAML, Properties, Env and finally Overrides.
+type Fixture struct {
+	// Profile defaults to "test" and may list several profiles separated by commas.
+	Profile string
+	// FS is searched like the embedded FS of an application, e.g. an fstest.MapFS.
+	FS               fs.FS
+	EmbedSearchRoots []string
+	// YAML documents are layered in order.
+	YAML []string
+	// Properties use flat keys such as "server.port" or "hosts[0]".
+	Properties map[string]interface{}
+	// Env fakes environment variables such as SERVER_PORT.
+	Env       map[string]string
+	Overrides map[string]interface{}
+	Validator *configuration.ConfigurationValidator
+}
+
+type yamlSource struct {
+	name    string
+	content string
+}
+
+func (s yamlSource) Name() string {
+	return s.name
+}
+
+func (s yamlSource) Read() ([]byte, error) {
+	return []byte(s.content), nil
+}
+
+// Load builds the configuration described by fixture.
+func Load[T any](fixture Fixture) (*configuration.Configuration[T], error) {
+	profile := fixture.Profile
+	if profile == "" {
+		profile = DefaultProfile
+	}
+
+	var sources []configuration.PropertySource
+	for i, content := range fixture.YAML {
+		sources = append(sources, yamlSource{name: fmt.Sprintf("inline:yaml[%d]", i), content: content})
+	}
+	if fixture.Properties != nil {
+		sources = append(sources, configuration.NewMapPropertySource("inline:properties", fixture.Properties))
+	}
+	if fixture.FS == nil && len(sources) == 0 {
+		// An empty fixture still describes a valid, zero valued configuration.
+		sources = append(sources, yamlSource{name: "inline:empty"})
+	}
+
+	return configuration.NewConfigurationWithOptions[T](fixture.FS, profile, configuration.Options{
+		Validator:        fixture.Validator,
+		PropertySources:  sources,
+		EmbedSearchRoots: fixture.EmbedSearchRoots,
+		IgnoreWorkingDir: true,
+		LookupEnv:        fixture.lookupEnv,
+		Overrides:        fixture.Overrides,
+	})
+}
+
+func (f Fixture) lookupEnv(key string) (string, bool) {
+	value, ok := f.Env[key]
+	return value, ok
+}
+
+// New builds the configuration and fails the test when loading fails.
+func New[T any](t testing.TB, fixture Fixture) *configuration.Configuration[T] {
+	t.Helper()
+	config, err := Load[T](fixture)
+	if err != nil {
+		t.Fatalf("configtest: failed to load configuration: %v", err)
+	}
+	return config
+}
+
+// ExpectLoadError fails the test unless loading fails with an error whose
+// message contains every fragment.
+func ExpectLoadError[T any](t testing.TB, fixture Fixture, fragments ...string) error {
+	t.Helper()
+	_, err := Load[T](fixture)
+	if err == nil {
+		t.Fatalf("configtest: expected loading to fail")
+		return nil
+	}
+	for _, fragment := range fragments {
+		if !strings.Contains(err.Error(), fragment) {
+			t.Errorf("configtest: error %q does not contain %q", err, fragment)
+		}
+	}
+	return err
+}
+
+// ExpectValidationError fails the test unless loading fails validation and
+// every named field is reported. Without Fixture.Validator the `validate`
+// struct tags of T are checked.
+func ExpectValidationError[T any](t testing.TB, fixture Fixture, fields ...string) error {
+	t.Helper()
+
+	config, err := Load[T](fixture)
+	if err == nil && fixture.Validator == nil {
+		err = configuration.ValidateStruct(config.GetConfiguration())
+	}
+	if err == nil {
+		t.Fatalf("configtest: expected validation to fail")
+		return nil
+	}
+
+	var validationErr errors.ValidationError
+	var validationErrPtr *errors.ValidationError
+	if !stderrors.As(err, &validationErr) && !stderrors.As(err, &validationErrPtr) {
+		t.Fatalf("configtest: expected a validation error, got %v", err)
+		return err
+	}
+	for _, field := range fields {
+		if !strings.Contains(err.Error(), fmt.Sprintf("field '%s'", field)) {
+			t.Errorf("configtest: validation error %q does not report field %q", err, field)
+		}
+	}
+	return err
+}
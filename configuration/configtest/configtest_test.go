@@ -0,0 +1,85 @@
+package configtest
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/zbum/mantyboot/configuration"
+)
+
+type testConfiguration struct {
+	Name   string `yaml:"name" validate:"required"`
+	Server struct {
+		Host    string        `yaml:"host"`
+		Port    int           `yaml:"port"`
+		Timeout time.Duration `yaml:"timeout"`
+	} `yaml:"server"`
+	Hosts []string `yaml:"hosts"`
+}
+
+func TestLoad_Layers(t *testing.T) {
+	fixture := Fixture{
+		FS: fstest.MapFS{
+			"application-test.yaml": {Data: []byte("name: from-fs\nserver:\n  host: fs.example\n  port: 1\n")},
+		},
+		YAML: []string{
+			"server:\n  port: 2\n  timeout: 5s\n",
+			"server:\n  port: 3\n",
+		},
+		Properties: map[string]interface{}{"hosts[0]": "a", "hosts[1]": "b"},
+		Env:        map[string]string{"SERVER_HOST": "env.example", "HOSTS": "c, d"},
+		Overrides:  map[string]interface{}{"server.host": "override.example"},
+	}
+
+	config := New[testConfiguration](t, fixture).GetConfiguration()
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{name: "fs", got: config.Name, want: "from-fs"},
+		{name: "later yaml wins", got: config.Server.Port, want: 3},
+		{name: "earlier yaml kept", got: config.Server.Timeout, want: 5 * time.Second},
+		{name: "env over properties", got: config.Hosts, want: []string{"c", "d"}},
+		{name: "overrides over env", got: config.Server.Host, want: "override.example"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_Profiles(t *testing.T) {
+	fixture := Fixture{
+		Profile: "prod",
+		FS: fstest.MapFS{
+			"config/application-prod.yaml": {Data: []byte("name: prod\n")},
+			"config/application-test.yaml": {Data: []byte("name: test\n")},
+		},
+	}
+
+	if got := New[testConfiguration](t, fixture).GetConfiguration().Name; got != "prod" {
+		t.Errorf("name = %q, want prod", got)
+	}
+}
+
+func TestExpectValidationError(t *testing.T) {
+	ExpectValidationError[testConfiguration](t, Fixture{YAML: []string{"server:\n  port: 8080\n"}}, "Name")
+
+	validator := configuration.NewConfigurationValidator()
+	max := 1024
+	validator.AddRule("Server", configuration.ValidationRule{Field: "Server", Required: true})
+	validator.AddRule("Name", configuration.ValidationRule{Field: "Name", Required: true, MaxLength: &max})
+	ExpectValidationError[testConfiguration](t, Fixture{Validator: validator}, "Name", "Server")
+}
+
+func TestExpectLoadError(t *testing.T) {
+	ExpectLoadError[testConfiguration](t, Fixture{YAML: []string{"server:\n  port: abc\n"}},
+		`server.port: "abc" at inline:yaml[0]:2:9 cannot bind to int`)
+}
@@ -1,168 +1,363 @@
 package configuration
 
 import (
-	"embed"
+	stderrors "errors"
+	"fmt"
 	"gopkg.in/yaml.v3"
-	"io"
 	"io/fs"
+	"log"
 	"os"
+	"path"
+	"reflect"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/zbum/mantyboot/errors"
 )
 
+var defaultLogger = log.New(os.Stderr, "[mantyboot]", log.LstdFlags)
+
 type Configuration[T any] struct {
-	embedDir embed.FS
-	profile  string
-	payload  *T
+	embedDir  fs.FS
+	profile   string
+	options   Options
 	validator *ConfigurationValidator
+
+	mu          sync.RWMutex
+	payload     *T
+	environment *Environment
+	listeners   []func(*T)
+}
+
+type Options struct {
+	// Validator, when set, is applied on load and on every reload.
+	Validator *ConfigurationValidator
+	// PropertySources are layered after the built-in sources and take precedence over them.
+	PropertySources []PropertySource
+	// Logger receives warnings such as the use of deprecated keys.
+	Logger *log.Logger
+	// EmbedSearchRoots lists the embedded directories searched for
+	// application-{profile}.yaml, from lowest to highest precedence.
+	EmbedSearchRoots []string
+	// IgnoreWorkingDir skips ./application-{profile}.yaml and
+	// ./config/application-{profile}.yaml.
+	IgnoreWorkingDir bool
+	// LookupEnv enables environment variable overrides, e.g. os.LookupEnv.
+	// A key such as "server.max-conns" is read from SERVER_MAX_CONNS.
+	LookupEnv func(key string) (string, bool)
+	// Overrides are flat keys such as "server.port" applied above every other source.
+	Overrides map[string]interface{}
 }
 
-func NewConfiguration[T any](embedDir embed.FS, profile string) (*Configuration[T], error) {
+func NewConfiguration[T any](embedDir fs.FS, profile string) (*Configuration[T], error) {
 	c := &Configuration[T]{
-		embedDir: embedDir,
-		profile:  profile,
+		embedDir:  embedDir,
+		profile:   profile,
 		validator: NewConfigurationValidator(),
 	}
-	_, err := c.load()
-	if err != nil {
-		return nil, errors.WrapConfigurationError(err, "failed to load configuration")
+	if err := c.init(); err != nil {
+		return nil, err
 	}
 	return c, nil
 }
 
-func NewConfigurationWithValidation[T any](embedDir embed.FS, profile string, validator *ConfigurationValidator) (*Configuration[T], error) {
+func NewConfigurationWithValidation[T any](embedDir fs.FS, profile string, validator *ConfigurationValidator) (*Configuration[T], error) {
+	return NewConfigurationWithOptions[T](embedDir, profile, Options{Validator: validator})
+}
+
+func NewConfigurationWithOptions[T any](embedDir fs.FS, profile string, options Options) (*Configuration[T], error) {
 	c := &Configuration[T]{
-		embedDir: embedDir,
-		profile:  profile,
-		validator: validator,
+		embedDir:  embedDir,
+		profile:   profile,
+		options:   options,
+		validator: options.Validator,
+	}
+	if err := c.init(); err != nil {
+		return nil, err
 	}
-	_, err := c.load()
+	return c, nil
+}
+
+func (c *Configuration[T]) init() error {
+	payload, environment, err := c.load()
 	if err != nil {
-		return nil, errors.WrapConfigurationError(err, "failed to load configuration")
+		return errors.WrapConfigurationError(err, "failed to load configuration")
 	}
-	
+
 	// Validate configuration if validator is provided
-	if validator != nil {
-		if err := validator.Validate(c.payload); err != nil {
-			return nil, errors.WrapConfigurationError(err, "configuration validation failed")
+	if c.options.Validator != nil {
+		if err := c.options.Validator.Validate(payload); err != nil {
+			return errors.WrapConfigurationError(err, "configuration validation failed")
 		}
 	}
-	
-	return c, nil
+
+	c.payload, c.environment = payload, environment
+	return nil
 }
 
+// GetConfiguration returns the current payload. A successful Reload replaces
+// it with a new value, so callers should not hold on to the pointer.
 func (c *Configuration[T]) GetConfiguration() *T {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.payload
 }
 
+func (c *Configuration[T]) GetEnvironment() *Environment {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.environment
+}
+
+// AcceptsProfiles evaluates a profile expression such as "prod & !kr"
+// against the active profiles, including those added by profile groups.
+func (c *Configuration[T]) AcceptsProfiles(expression string) (bool, error) {
+	return c.GetEnvironment().AcceptsProfiles(expression)
+}
+
 func (c *Configuration[T]) Validate() error {
 	if c.validator == nil {
 		return nil
 	}
-	return c.validator.Validate(c.payload)
+	return c.validator.Validate(c.GetConfiguration())
 }
 
-func (c *Configuration[T]) load() (*T, error) {
-	readers := c.findConfigurationFiles(c.profile)
-	if len(readers) == 0 {
-		return nil, errors.WrapConfigurationError(nil, "no configuration files found for profile: "+c.profile)
+// Reload reads every property source again. The current configuration is
+// kept when loading or validation fails.
+func (c *Configuration[T]) Reload() error {
+	payload, environment, err := c.load()
+	if err != nil {
+		return errors.WrapConfigurationError(err, "failed to reload configuration")
 	}
-	
-	for _, reader := range readers {
-		defer reader.Close()
-		bytes, err := io.ReadAll(reader)
-		if err != nil {
-			return nil, errors.WrapConfigurationError(err, "failed to read configuration file")
-		}
 
-		if err := c.parse(bytes); err != nil {
-			return nil, errors.WrapConfigurationError(err, "failed to parse configuration")
+	if c.options.Validator != nil {
+		if err := c.options.Validator.Validate(payload); err != nil {
+			return errors.WrapConfigurationError(err, "configuration validation failed")
 		}
 	}
 
-	return c.payload, nil
-}
+	c.mu.Lock()
+	c.payload, c.environment = payload, environment
+	listeners := append([](func(*T)){}, c.listeners...)
+	c.mu.Unlock()
 
-func (c *Configuration[T]) parse(input []byte) error {
-	if c.payload == nil {
-		var config T
-		c.payload = &config
+	for _, listener := range listeners {
+		listener(payload)
 	}
-	
-	err := yaml.Unmarshal(input, c.payload)
-	if err != nil {
-		return errors.WrapConfigurationError(err, "failed to unmarshal YAML")
+	return nil
+}
+
+func (c *Configuration[T]) logger() *log.Logger {
+	if c.options.Logger != nil {
+		return c.options.Logger
 	}
+	return defaultLogger
+}
 
-	return nil
+// OnReload registers a listener called with the new payload after every successful Reload.
+func (c *Configuration[T]) OnReload(listener func(*T)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listeners = append(c.listeners, listener)
 }
 
-func (c *Configuration[T]) findConfigurationFiles(profile string) []io.ReadCloser {
-	var files []io.ReadCloser
+// maxProfileExpansions bounds how often profile groups found in newly loaded
+// files may add further profiles.
+const maxProfileExpansions = 8
 
-	firstCandidate := c.findFirstCandidate(profile)
-	if firstCandidate != nil {
-		files = append(files, firstCandidate)
-	}
+func (c *Configuration[T]) load() (*T, *Environment, error) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	rules := newMergeRules(typ)
+	metadata := generateMetadata(typ)
+
+	requested := parseProfileList(c.profile)
+	profiles := requested
+
+	var root *yaml.Node
+	var origins nodeOrigins
+	for expansion := 0; ; expansion++ {
+		var err error
+		root, origins, err = c.loadProfiles(profiles, metadata, rules)
+		if err != nil {
+			return nil, nil, err
+		}
 
-	secondCandidate := c.findSecondCandidate(profile)
-	if secondCandidate != nil {
-		files = append(files, secondCandidate)
+		expanded := expandProfileGroups(requested, profileGroups(root))
+		if slices.Equal(expanded, profiles) || expansion == maxProfileExpansions {
+			break
+		}
+		profiles = expanded
 	}
+	root = metadata.applyDeprecations(root, origins, rules, c.logger().Printf)
 
-	thirdCandidate := c.findThirdCandidate(profile)
-	if thirdCandidate != nil {
-		files = append(files, thirdCandidate)
+	payload, err := c.parse(root)
+	if err != nil {
+		return nil, nil, errors.WrapConfigurationError(bindError(root, origins, typ, err), "failed to parse configuration")
 	}
 
-	return files
+	return payload, newEnvironment(root, profiles), nil
 }
 
-func (c *Configuration[T]) findFirstCandidate(profile string) io.ReadCloser {
-	var property io.ReadCloser
-	fs.WalkDir(c.embedDir, ".", func(path string, d fs.DirEntry, err error) error {
+// loadProfiles merges the files of every profile in order, so a later profile
+// overrides an earlier one. Options.PropertySources, environment variables and
+// Options.Overrides follow, in that order.
+func (c *Configuration[T]) loadProfiles(profiles []string, metadata Metadata, rules *mergeRules) (*yaml.Node, nodeOrigins, error) {
+	var sources []PropertySource
+	for _, profile := range profiles {
+		profileSources, err := c.propertySources(profile)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
-		if strings.Contains(path, "application-"+profile+".yaml") {
-			property, err = c.embedDir.Open(path)
-			if err != nil {
-				return err
+		sources = append(sources, profileSources...)
+	}
+	sources = append(sources, c.options.PropertySources...)
+
+	found := false
+	origins := nodeOrigins{}
+	root := metadata.defaultsNode()
+	origins.record(defaultsSource, root)
+
+	for _, source := range sources {
+		bytes, err := source.Read()
+		if err != nil {
+			if stderrors.Is(err, fs.ErrNotExist) {
+				continue
 			}
-			return nil
+			return nil, nil, errors.WrapConfigurationError(err, "failed to read configuration file "+source.Name())
 		}
-		return nil
-	})
-	return property
-}
+		found = true
 
-func (c *Configuration[T]) findSecondCandidate(profile string) io.ReadCloser {
-	wd, err := os.Getwd()
-	if err != nil {
-		return nil
+		documents, err := parseDocuments(source.Name(), bytes)
+		if err != nil {
+			return nil, nil, errors.WrapConfigurationError(err, "failed to parse configuration")
+		}
+
+		for _, document := range documents {
+			guard, document := extractProfileGuard(document)
+			if guard != "" {
+				expression, err := ParseProfileExpression(guard)
+				if err != nil {
+					return nil, nil, errors.WrapConfigurationError(LocationError{
+						Source:  source.Name(),
+						Line:    document.Line,
+						Column:  document.Column,
+						Key:     profileGuardKey,
+						Message: err.Error(),
+					}, "failed to parse configuration")
+				}
+				if !expression.Matches(profiles) {
+					continue
+				}
+			}
+
+			origins.record(source.Name(), document)
+			root = mergeNodes(root, document, rules)
+		}
 	}
 
-	property, err := os.Open(wd + "/application-" + profile + ".yaml")
+	if !found {
+		return nil, nil, errors.WrapConfigurationError(nil, "no configuration files found for profile: "+strings.Join(profiles, ","))
+	}
 
-	if err != nil {
-		return nil
+	if c.options.LookupEnv != nil {
+		overlay := environmentOverlay(root, metadata, c.options.LookupEnv)
+		origins.record("environment", overlay)
+		root = mergeNodes(root, overlay, rules)
+	}
+
+	if len(c.options.Overrides) > 0 {
+		overrides := NewMapPropertySource("overrides", c.options.Overrides)
+		bytes, err := overrides.Read()
+		if err != nil {
+			return nil, nil, errors.WrapConfigurationError(err, "failed to read configuration overrides")
+		}
+		documents, err := parseDocuments(overrides.Name(), bytes)
+		if err != nil {
+			return nil, nil, errors.WrapConfigurationError(err, "failed to parse configuration")
+		}
+		for _, document := range documents {
+			origins.record(overrides.Name(), document)
+			root = mergeNodes(root, document, rules)
+		}
 	}
 
-	return property
+	return root, origins, nil
 }
 
-func (c *Configuration[T]) findThirdCandidate(profile string) io.ReadCloser {
-	wd, err := os.Getwd()
-	if err != nil {
-		return nil
+func (c *Configuration[T]) parse(root *yaml.Node) (*T, error) {
+	var config T
+	if root == nil {
+		return &config, nil
 	}
 
-	property, err := os.Open(wd + "/config/application-" + profile + ".yaml")
+	if err := root.Decode(&config); err != nil {
+		return nil, err
+	}
 
-	if err != nil {
+	return &config, nil
+}
+
+func (c *Configuration[T]) propertySources(profile string) ([]PropertySource, error) {
+	var sources []PropertySource
+
+	if c.embedDir != nil {
+		paths, err := c.findEmbeddedCandidates(profile)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			sources = append(sources, NewFSPropertySource(c.embedDir, path))
+		}
+	}
+
+	if !c.options.IgnoreWorkingDir {
+		sources = append(sources,
+			NewFilePropertySource("./application-"+profile+".yaml"),
+			NewFilePropertySource("./config/application-"+profile+".yaml"),
+		)
+	}
+
+	return sources, nil
+}
+
+// findEmbeddedCandidates returns the embedded files for profile in merge order.
+// With EmbedSearchRoots every root is checked, in order. Without them the
+// whole FS is searched and more than one match is reported as ambiguous.
+func (c *Configuration[T]) findEmbeddedCandidates(profile string) ([]string, error) {
+	name := "application-" + profile + ".yaml"
+
+	if len(c.options.EmbedSearchRoots) > 0 {
+		var paths []string
+		for _, root := range c.options.EmbedSearchRoots {
+			candidate := path.Join(root, name)
+			info, err := fs.Stat(c.embedDir, candidate)
+			if err != nil || info.IsDir() {
+				continue
+			}
+			paths = append(paths, candidate)
+		}
+		return paths, nil
+	}
+
+	var paths []string
+	err := fs.WalkDir(c.embedDir, ".", func(current string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && d.Name() == name {
+			paths = append(paths, current)
+		}
 		return nil
+	})
+	if err != nil && !stderrors.Is(err, fs.ErrNotExist) {
+		return nil, errors.WrapConfigurationError(err, "failed to search embedded configuration")
 	}
 
-	return property
+	if len(paths) > 1 {
+		return nil, errors.WrapConfigurationError(nil, fmt.Sprintf(
+			"ambiguous embedded configuration for profile %s: %s; set Options.EmbedSearchRoots to choose the files and their order",
+			profile, strings.Join(paths, ", ")))
+	}
+	return paths, nil
 }
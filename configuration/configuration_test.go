@@ -0,0 +1,80 @@
+package configuration
+
+import (
+	"embed"
+	"strings"
+	"testing"
+)
+
+//go:embed testdata/embed
+var layeredFs embed.FS
+
+type layeredConfiguration struct {
+	Name   string `yaml:"name"`
+	Server struct {
+		Host string `yaml:"host"`
+		Port int    `yaml:"port"`
+	} `yaml:"server"`
+}
+
+func TestConfiguration_EmbedSearchRoots(t *testing.T) {
+	tests := []struct {
+		name     string
+		profile  string
+		roots    []string
+		wantHost string
+		wantPort int
+		wantName string
+		wantErr  string
+	}{
+		{
+			name:    "ambiguous without roots",
+			profile: "layer",
+			wantErr: "testdata/embed/application-layer.yaml, testdata/embed/config/application-layer.yaml",
+		},
+		{
+			name:     "single match without roots",
+			profile:  "single",
+			wantName: "single",
+		},
+		{
+			name:     "roots in precedence order",
+			profile:  "layer",
+			roots:    []string{"testdata/embed", "testdata/embed/config"},
+			wantHost: "localhost",
+			wantPort: 9090,
+		},
+		{
+			name:     "reversed roots",
+			profile:  "layer",
+			roots:    []string{"testdata/embed/config", "testdata/embed"},
+			wantHost: "localhost",
+			wantPort: 8080,
+		},
+		{
+			name:     "missing root is skipped",
+			profile:  "layer",
+			roots:    []string{"testdata/embed/config", "testdata/missing"},
+			wantPort: 9090,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewConfigurationWithOptions[layeredConfiguration](layeredFs, tt.profile, Options{EmbedSearchRoots: tt.roots, IgnoreWorkingDir: true})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("NewConfigurationWithOptions() error = %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewConfigurationWithOptions() error = %v", err)
+			}
+
+			config := got.GetConfiguration()
+			if config.Server.Host != tt.wantHost || config.Server.Port != tt.wantPort || config.Name != tt.wantName {
+				t.Errorf("GetConfiguration() = %+v", *config)
+			}
+		})
+	}
+}
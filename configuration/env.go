@@ -0,0 +1,71 @@
+package configuration
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// environmentOverlay builds a layer from environment variables named after
+// the keys of the configuration type and of the loaded files.
+func environmentOverlay(root *yaml.Node, metadata Metadata, lookupEnv func(string) (string, bool)) *yaml.Node {
+	lists := map[string]bool{}
+	keys := map[string]bool{}
+	for _, property := range metadata.Properties {
+		if strings.Contains(property.Name, "*") {
+			continue
+		}
+		keys[property.Name] = true
+		lists[property.Name] = strings.HasPrefix(property.Type, "[]")
+	}
+	collectLeafKeys(root, "", keys)
+
+	overlay := &yaml.Node{Kind: yaml.MappingNode}
+	for key := range keys {
+		value, ok := lookupEnv(EnvironmentVariableName(key))
+		if !ok {
+			continue
+		}
+
+		node := &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+		if lists[key] {
+			node = &yaml.Node{Kind: yaml.SequenceNode}
+			for _, item := range strings.Split(value, ",") {
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: strings.TrimSpace(item)})
+			}
+		}
+		setNode(overlay, key, node)
+	}
+
+	if len(overlay.Content) == 0 {
+		return nil
+	}
+	return overlay
+}
+
+// EnvironmentVariableName returns the variable that overrides key, for
+// example SERVER_MAX_CONNS for "server.max-conns".
+func EnvironmentVariableName(key string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+func collectLeafKeys(node *yaml.Node, prefix string, keys map[string]bool) {
+	node = resolveAlias(node)
+	if node == nil {
+		return
+	}
+	if node.Kind != yaml.MappingNode {
+		if prefix != "" {
+			keys[prefix] = true
+		}
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		collectLeafKeys(node.Content[i+1], key, keys)
+	}
+}
@@ -0,0 +1,157 @@
+package configuration
+
+import (
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Environment gives untyped access to the merged property sources, addressed
+// by dotted keys such as "server.port".
+type Environment struct {
+	root     *yaml.Node
+	profiles []string
+}
+
+func newEnvironment(root *yaml.Node, profiles []string) *Environment {
+	return &Environment{
+		root:     root,
+		profiles: profiles,
+	}
+}
+
+func (e *Environment) ActiveProfiles() []string {
+	return append([]string(nil), e.profiles...)
+}
+
+func (e *Environment) AcceptsProfiles(expression string) (bool, error) {
+	parsed, err := ParseProfileExpression(expression)
+	if err != nil {
+		return false, err
+	}
+	return parsed.Matches(e.profiles), nil
+}
+
+func (e *Environment) IsSet(key string) bool {
+	node := e.lookup(key)
+	return node != nil && node.ShortTag() != "!!null"
+}
+
+// Bind decodes the value at key into target, which must be a pointer.
+func (e *Environment) Bind(key string, target interface{}) error {
+	node := e.lookup(key)
+	if node == nil {
+		return nil
+	}
+	return node.Decode(target)
+}
+
+func (e *Environment) GetString(key string) string {
+	var value string
+	e.decodeScalar(key, &value)
+	return value
+}
+
+func (e *Environment) GetInt(key string) int {
+	var value int
+	e.decodeScalar(key, &value)
+	return value
+}
+
+func (e *Environment) GetBool(key string) bool {
+	var value bool
+	e.decodeScalar(key, &value)
+	return value
+}
+
+func (e *Environment) GetDuration(key string) time.Duration {
+	var value time.Duration
+	e.decodeScalar(key, &value)
+	return value
+}
+
+// GetStringSlice accepts either a YAML sequence or a comma separated scalar.
+func (e *Environment) GetStringSlice(key string) []string {
+	node := e.lookup(key)
+	if node == nil {
+		return nil
+	}
+
+	switch node.Kind {
+	case yaml.SequenceNode:
+		var values []string
+		if err := node.Decode(&values); err != nil {
+			return nil
+		}
+		return values
+	case yaml.ScalarNode:
+		if node.Value == "" {
+			return nil
+		}
+		values := strings.Split(node.Value, ",")
+		for i := range values {
+			values[i] = strings.TrimSpace(values[i])
+		}
+		return values
+	}
+	return nil
+}
+
+// Sub returns the environment rooted at key. Keys missing from the current
+// environment yield an empty one, so calls can be chained safely.
+func (e *Environment) Sub(key string) *Environment {
+	node := e.lookup(key)
+	if node != nil && node.Kind != yaml.MappingNode {
+		node = nil
+	}
+	return newEnvironment(node, e.profiles)
+}
+
+func (e *Environment) decodeScalar(key string, target interface{}) {
+	node := e.lookup(key)
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return
+	}
+	node.Decode(target)
+}
+
+func (e *Environment) lookup(key string) *yaml.Node {
+	if e == nil {
+		return nil
+	}
+	if key == "" {
+		return e.root
+	}
+	return lookupNode(e.root, key)
+}
+
+func lookupNode(node *yaml.Node, key string) *yaml.Node {
+	node = resolveAlias(node)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	// Flat keys such as "server.port: 8080" take precedence over nesting.
+	if index := mappingIndex(node, key); index >= 0 {
+		return resolveAlias(node.Content[index+1])
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		prefix := node.Content[i].Value + "."
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if found := lookupNode(node.Content[i+1], key[len(prefix):]); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
@@ -0,0 +1,122 @@
+package configuration
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const environmentSample = `
+server:
+  host: localhost
+  port: 8080
+  timeout: 30s
+  enabled: true
+database:
+  url: mysql://localhost:3306/test
+  max-conns: 10
+cors.allowed-origins: http://a.example, http://b.example
+tags:
+  - alpha
+  - beta
+`
+
+func parseSingleDocument(source string, input []byte) (*yaml.Node, error) {
+	roots, err := parseDocuments(source, input)
+	if err != nil || len(roots) == 0 {
+		return nil, err
+	}
+	return roots[0], nil
+}
+
+func newSampleEnvironment(t *testing.T) *Environment {
+	root, err := parseSingleDocument("test", []byte(environmentSample))
+	if err != nil {
+		t.Fatalf("parseSingleDocument() error = %v", err)
+	}
+	return newEnvironment(root, []string{"dev"})
+}
+
+func TestEnvironment_Getters(t *testing.T) {
+	env := newSampleEnvironment(t)
+
+	if got := env.GetString("server.host"); got != "localhost" {
+		t.Errorf("GetString() = %v, want %v", got, "localhost")
+	}
+	if got := env.GetInt("server.port"); got != 8080 {
+		t.Errorf("GetInt() = %v, want %v", got, 8080)
+	}
+	if got := env.GetBool("server.enabled"); !got {
+		t.Errorf("GetBool() = %v, want %v", got, true)
+	}
+	if got := env.GetDuration("server.timeout"); got != 30*time.Second {
+		t.Errorf("GetDuration() = %v, want %v", got, 30*time.Second)
+	}
+	if got := env.GetInt("server.host"); got != 0 {
+		t.Errorf("GetInt() on non-numeric value = %v, want 0", got)
+	}
+	if got := env.GetString("server.missing"); got != "" {
+		t.Errorf("GetString() on missing key = %v, want empty", got)
+	}
+}
+
+func TestEnvironment_GetStringSlice(t *testing.T) {
+	env := newSampleEnvironment(t)
+
+	tests := []struct {
+		name string
+		key  string
+		want []string
+	}{
+		{name: "sequence", key: "tags", want: []string{"alpha", "beta"}},
+		{name: "comma separated flat key", key: "cors.allowed-origins", want: []string{"http://a.example", "http://b.example"}},
+		{name: "missing", key: "nothing", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := env.GetStringSlice(tt.key); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetStringSlice() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvironment_SubAndIsSet(t *testing.T) {
+	env := newSampleEnvironment(t)
+
+	database := env.Sub("database")
+	if got := database.GetInt("max-conns"); got != 10 {
+		t.Errorf("Sub().GetInt() = %v, want %v", got, 10)
+	}
+	if !database.IsSet("url") || database.IsSet("server.host") {
+		t.Errorf("Sub().IsSet() did not scope keys to the sub tree")
+	}
+	if env.Sub("missing").Sub("deeper").IsSet("key") {
+		t.Errorf("IsSet() on empty sub environment = true, want false")
+	}
+	if !reflect.DeepEqual(database.ActiveProfiles(), []string{"dev"}) {
+		t.Errorf("ActiveProfiles() = %v, want [dev]", database.ActiveProfiles())
+	}
+}
+
+func TestMergeNodes(t *testing.T) {
+	base, _ := parseSingleDocument("test", []byte("a: 1\nnested:\n  x: 1\n  y: 2\nlist: [1, 2]\n"))
+	override, _ := parseSingleDocument("test", []byte("nested:\n  y: 3\nlist: [4]\n"))
+
+	env := newEnvironment(mergeNodes(base, override, nil), nil)
+
+	if got := env.GetInt("nested.x"); got != 1 {
+		t.Errorf("nested.x = %v, want 1", got)
+	}
+	if got := env.GetInt("nested.y"); got != 3 {
+		t.Errorf("nested.y = %v, want 3", got)
+	}
+	if got := env.GetStringSlice("list"); !reflect.DeepEqual(got, []string{"4"}) {
+		t.Errorf("list = %v, want [4]", got)
+	}
+	if got := newEnvironment(base, nil).GetInt("nested.y"); got != 2 {
+		t.Errorf("mergeNodes() modified its input, nested.y = %v", got)
+	}
+}
@@ -0,0 +1,219 @@
+package configuration
+
+import (
+	stderrors "errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LocationError points at the property source, line and column that could
+// not be parsed or bound to the configuration type.
+type LocationError struct {
+	Source  string
+	Line    int
+	Column  int
+	Key     string
+	Value   string
+	Target  string
+	Message string
+}
+
+func (e LocationError) Error() string {
+	location := e.Source
+	if e.Line > 0 {
+		location += ":" + strconv.Itoa(e.Line)
+		if e.Column > 0 {
+			location += ":" + strconv.Itoa(e.Column)
+		}
+	}
+
+	if e.Target != "" {
+		return fmt.Sprintf("%s: %q at %s cannot bind to %s", e.Key, e.Value, location, e.Target)
+	}
+	if e.Key != "" {
+		return fmt.Sprintf("%s at %s: %s", e.Key, location, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", location, e.Message)
+}
+
+var syntaxErrorPattern = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+func syntaxError(source string, err error) error {
+	matches := syntaxErrorPattern.FindStringSubmatch(err.Error())
+	if matches == nil {
+		return LocationError{Source: source, Message: strings.TrimPrefix(err.Error(), "yaml: ")}
+	}
+	line, _ := strconv.Atoi(matches[1])
+	return LocationError{Source: source, Line: line, Message: matches[2]}
+}
+
+// nodeOrigins remembers which property source every parsed node came from.
+// Merging copies mappings and sequences but keeps their children, so the
+// origin of a copy is found through its first child.
+type nodeOrigins map[*yaml.Node]string
+
+func (o nodeOrigins) record(source string, node *yaml.Node) {
+	if node == nil {
+		return
+	}
+	o[node] = source
+	for _, child := range node.Content {
+		o.record(source, child)
+	}
+}
+
+// defaultsSource is the origin of values that come from `default` tags.
+const defaultsSource = "default"
+
+// setExplicitly reports whether node, or any node below it, came from a
+// property source rather than from a `default` tag.
+func (o nodeOrigins) setExplicitly(node *yaml.Node) bool {
+	if node == nil {
+		return false
+	}
+	if source, ok := o[node]; ok && source != defaultsSource && (node.Kind == yaml.ScalarNode || len(node.Content) == 0) {
+		return true
+	}
+	for _, child := range node.Content {
+		if o.setExplicitly(child) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o nodeOrigins) sourceOf(node *yaml.Node) string {
+	for node != nil {
+		if source, ok := o[node]; ok {
+			return source
+		}
+		if len(node.Content) == 0 {
+			break
+		}
+		node = node.Content[0]
+	}
+	return ""
+}
+
+// bindError locates the values of a failed yaml.TypeError. Rather than parse
+// the messages of the error, it decodes the tree again value by value against
+// typ, so every failing node is known together with its origin.
+func bindError(root *yaml.Node, origins nodeOrigins, typ reflect.Type, err error) error {
+	var typeErr *yaml.TypeError
+	if !stderrors.As(err, &typeErr) {
+		return err
+	}
+
+	located := locateBindErrors(root, "", typ, origins)
+	if len(located) == 0 {
+		return err
+	}
+	return stderrors.Join(located...)
+}
+
+func locateBindErrors(node *yaml.Node, key string, typ reflect.Type, origins nodeOrigins) []error {
+	if node == nil {
+		return nil
+	}
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		return locateBindErrors(node.Content[0], key, typ, origins)
+	}
+	typ = indirectType(typ)
+
+	var typeErr *yaml.TypeError
+	if err := node.Decode(reflect.New(typ).Interface()); !stderrors.As(err, &typeErr) {
+		return nil
+	}
+
+	value := node
+	if value.Kind == yaml.AliasNode && value.Alias != nil {
+		value = value.Alias
+	}
+
+	// Descend to the values that fail, unless typ decodes itself.
+	var located []error
+	if !decodesItself(typ) {
+		switch {
+		case value.Kind == yaml.MappingNode && typ.Kind() == reflect.Struct:
+			for i := 0; i+1 < len(value.Content); i += 2 {
+				if fieldType, ok := yamlFieldType(typ, value.Content[i].Value); ok {
+					located = append(located, locateBindErrors(value.Content[i+1], childKey(key, value.Content[i].Value), fieldType, origins)...)
+				}
+			}
+		case value.Kind == yaml.MappingNode && typ.Kind() == reflect.Map:
+			for i := 0; i+1 < len(value.Content); i += 2 {
+				located = append(located, locateBindErrors(value.Content[i+1], childKey(key, value.Content[i].Value), typ.Elem(), origins)...)
+			}
+		case value.Kind == yaml.SequenceNode && (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array):
+			for i, child := range value.Content {
+				located = append(located, locateBindErrors(child, fmt.Sprintf("%s[%d]", key, i), typ.Elem(), origins)...)
+			}
+		}
+	}
+	if len(located) > 0 {
+		return located
+	}
+
+	if value.Kind != yaml.ScalarNode {
+		return []error{LocationError{
+			Source:  origins.sourceOf(value),
+			Line:    value.Line,
+			Column:  value.Column,
+			Key:     key,
+			Message: fmt.Sprintf("cannot bind %s to %s", value.ShortTag(), typ),
+		}}
+	}
+	return []error{LocationError{
+		Source: origins.sourceOf(value),
+		Line:   value.Line,
+		Column: value.Column,
+		Key:    key,
+		Value:  value.Value,
+		Target: typ.String(),
+	}}
+}
+
+// decodesItself reports whether typ takes over its own decoding, so that
+// its nodes cannot be checked one by one.
+func decodesItself(typ reflect.Type) bool {
+	pointer := reflect.PointerTo(typ)
+	return typ == timeType || pointer.Implements(yamlUnmarshalerType) || pointer.Implements(textUnmarshalerType)
+}
+
+// yamlFieldType returns the type of the field of the struct typ that yaml.v3
+// decodes key into, looking through inline fields.
+func yamlFieldType(typ reflect.Type, key string) (reflect.Type, bool) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, inline := yamlFieldName(field)
+		switch {
+		case name == "-":
+			continue
+		case inline && indirectType(field.Type).Kind() == reflect.Struct:
+			if fieldType, ok := yamlFieldType(indirectType(field.Type), key); ok {
+				return fieldType, true
+			}
+		case inline && field.Type.Kind() == reflect.Map:
+			return field.Type.Elem(), true
+		case name == key:
+			return field.Type, true
+		}
+	}
+	return nil, false
+}
+
+func childKey(key, child string) string {
+	if key == "" {
+		return child
+	}
+	return key + "." + child
+}
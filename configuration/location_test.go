@@ -0,0 +1,114 @@
+package configuration
+
+import (
+	stderrors "errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseDocument_SyntaxError(t *testing.T) {
+	_, err := parseSingleDocument("./config/application-prod.yaml", []byte("server:\n  port: 8080\n bad: [\n"))
+
+	var located LocationError
+	if !stderrors.As(err, &located) {
+		t.Fatalf("parseSingleDocument() error = %v, want LocationError", err)
+	}
+	if located.Source != "./config/application-prod.yaml" || located.Line == 0 {
+		t.Errorf("parseSingleDocument() error = %+v, want source and line", located)
+	}
+}
+
+func TestBindError(t *testing.T) {
+	type target struct {
+		Server struct {
+			Host    string `yaml:"host"`
+			Port    int    `yaml:"port"`
+			Timeout int    `yaml:"timeout"`
+		} `yaml:"server"`
+		Name string `yaml:"name"`
+	}
+
+	layers := []struct {
+		source string
+		input  string
+	}{
+		{source: "embed:application-prod.yaml", input: "server:\n  host: localhost\n  timeout: a-very-long-timeout\n"},
+		{source: "./config/application-prod.yaml", input: "name: [a]\nserver:\n  port: \"abc\"\n"},
+	}
+
+	var root *yaml.Node
+	origins := nodeOrigins{}
+	for _, layer := range layers {
+		node, err := parseSingleDocument(layer.source, []byte(layer.input))
+		if err != nil {
+			t.Fatalf("parseSingleDocument() error = %v", err)
+		}
+		origins.record(layer.source, node)
+		root = mergeNodes(root, node, newMergeRules(reflect.TypeOf(target{})))
+	}
+
+	var config target
+	err := bindError(root, origins, reflect.TypeOf(config), root.Decode(&config))
+	if err == nil {
+		t.Fatalf("bindError() = nil, want error")
+	}
+
+	for _, want := range []string{
+		`server.port: "abc" at ./config/application-prod.yaml:3:9 cannot bind to int`,
+		`server.timeout: "a-very-long-timeout" at embed:application-prod.yaml:3:12 cannot bind to int`,
+		`name at ./config/application-prod.yaml:1:7: cannot bind !!seq to string`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("bindError() = %v, want it to contain %q", err, want)
+		}
+	}
+}
+
+func TestBindError_SameValueOnSameLine(t *testing.T) {
+	type target struct {
+		Server struct {
+			Port int `yaml:"port"`
+		} `yaml:"server"`
+		Pool struct {
+			Size int `yaml:"size"`
+		} `yaml:"pool"`
+	}
+
+	rules := newMergeRules(reflect.TypeOf(target{}))
+	origins := nodeOrigins{}
+	var root *yaml.Node
+	for _, layer := range []struct{ source, input string }{
+		{source: "application.yaml", input: "server:\n  port: abc\n"},
+		{source: "application-dev.yaml", input: "pool:\n  size: abc\n"},
+	} {
+		node, err := parseSingleDocument(layer.source, []byte(layer.input))
+		if err != nil {
+			t.Fatalf("parseSingleDocument() error = %v", err)
+		}
+		origins.record(layer.source, node)
+		root = mergeNodes(root, node, rules)
+	}
+
+	var config target
+	err := bindError(root, origins, reflect.TypeOf(config), root.Decode(&config))
+	for _, want := range []string{
+		`server.port: "abc" at application.yaml:2:9 cannot bind to int`,
+		`pool.size: "abc" at application-dev.yaml:2:9 cannot bind to int`,
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("bindError() = %v, want it to contain %q", err, want)
+		}
+	}
+}
+
+func TestConfiguration_BindErrorLocation(t *testing.T) {
+	_, err := NewConfigurationWithOptions[layeredConfiguration](layeredFs, "bind", Options{IgnoreWorkingDir: true})
+
+	want := `server.port: "abc" at embed:testdata/embed/application-bind.yaml:3:9 cannot bind to int`
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("NewConfigurationWithOptions() error = %v, want it to contain %q", err, want)
+	}
+}
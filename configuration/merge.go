@@ -0,0 +1,231 @@
+package configuration
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// parseDocuments returns the root mapping node of every non-empty document
+// in a YAML stream.
+func parseDocuments(source string, input []byte) ([]*yaml.Node, error) {
+	var roots []*yaml.Node
+
+	decoder := yaml.NewDecoder(bytes.NewReader(input))
+	for {
+		var document yaml.Node
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			return roots, nil
+		}
+		if err != nil {
+			return nil, syntaxError(source, err)
+		}
+
+		if len(document.Content) == 0 {
+			continue
+		}
+		root := document.Content[0]
+		if root.ShortTag() == "!!null" {
+			continue
+		}
+		if root.Kind != yaml.MappingNode {
+			return nil, LocationError{Source: source, Line: root.Line, Column: root.Column, Message: "configuration root must be a mapping"}
+		}
+		roots = append(roots, root)
+	}
+}
+
+// Merge strategies are chosen per field with the `merge` struct tag:
+//
+//	merge:"merge"   mappings are merged key by key (default for maps and structs)
+//	merge:"replace" the value of a later layer replaces the earlier one (default for everything else)
+//	merge:"append"  sequences of a later layer are appended to the earlier one
+//
+// An explicit null (`~`, `null` or an empty value) removes the key, so the
+// field is reset to its zero value.
+type mergeStrategy int
+
+const (
+	mergeDefault mergeStrategy = iota
+	mergeDeep
+	mergeReplace
+	mergeAppend
+)
+
+type mergeRules struct {
+	strategy mergeStrategy
+	children map[string]*mergeRules
+}
+
+// child returns the rules for key; "*" matches any key of a map field.
+func (r *mergeRules) child(key string) *mergeRules {
+	if r == nil {
+		return nil
+	}
+	if rules, ok := r.children[key]; ok {
+		return rules
+	}
+	return r.children["*"]
+}
+
+func (r *mergeRules) strategyFor() mergeStrategy {
+	if r == nil {
+		return mergeDefault
+	}
+	return r.strategy
+}
+
+func newMergeRules(typ reflect.Type) *mergeRules {
+	return buildMergeRules(typ, mergeDefault, map[reflect.Type]bool{})
+}
+
+func buildMergeRules(typ reflect.Type, strategy mergeStrategy, visiting map[reflect.Type]bool) *mergeRules {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	rules := &mergeRules{strategy: strategy}
+	if visiting[typ] {
+		return rules
+	}
+	visiting[typ] = true
+	defer delete(visiting, typ)
+
+	switch typ.Kind() {
+	case reflect.Map:
+		rules.children = map[string]*mergeRules{"*": buildMergeRules(typ.Elem(), mergeDefault, visiting)}
+	case reflect.Struct:
+		rules.children = map[string]*mergeRules{}
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			name, inline := yamlFieldName(field)
+			if name == "-" {
+				continue
+			}
+			child := buildMergeRules(field.Type, parseMergeStrategy(field.Tag.Get("merge")), visiting)
+			if inline {
+				for key, value := range child.children {
+					rules.children[key] = value
+				}
+				continue
+			}
+			rules.children[name] = child
+		}
+	}
+	return rules
+}
+
+func parseMergeStrategy(tag string) mergeStrategy {
+	switch strings.TrimSpace(tag) {
+	case "merge":
+		return mergeDeep
+	case "replace":
+		return mergeReplace
+	case "append":
+		return mergeAppend
+	}
+	return mergeDefault
+}
+
+// yamlFieldName mirrors the key yaml.v3 uses for a struct field.
+func yamlFieldName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("yaml")
+	name, options, _ := strings.Cut(tag, ",")
+	inline := false
+	for _, option := range strings.Split(options, ",") {
+		if option == "inline" {
+			inline = true
+		}
+	}
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	return name, inline
+}
+
+// mergeNodes layers src over dst following rules. Neither argument is modified.
+func mergeNodes(dst, src *yaml.Node, rules *mergeRules) *yaml.Node {
+	if dst == nil {
+		return removeNulls(src)
+	}
+	if src == nil {
+		return dst
+	}
+
+	switch rules.strategyFor() {
+	case mergeReplace:
+		return removeNulls(src)
+	case mergeAppend:
+		if dst.Kind == yaml.SequenceNode && src.Kind == yaml.SequenceNode {
+			appended := *dst
+			appended.Content = append(append([]*yaml.Node(nil), dst.Content...), src.Content...)
+			return &appended
+		}
+	}
+
+	if dst.Kind != yaml.MappingNode || src.Kind != yaml.MappingNode {
+		return removeNulls(src)
+	}
+
+	merged := *dst
+	merged.Content = append([]*yaml.Node(nil), dst.Content...)
+
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+
+		index := mappingIndex(&merged, key.Value)
+		if isNull(value) {
+			if index >= 0 {
+				merged.Content = append(merged.Content[:index], merged.Content[index+2:]...)
+			}
+			continue
+		}
+		if index < 0 {
+			merged.Content = append(merged.Content, key, removeNulls(value))
+			continue
+		}
+		merged.Content[index+1] = mergeNodes(merged.Content[index+1], value, rules.child(key.Value))
+	}
+
+	return &merged
+}
+
+// removeNulls drops null valued keys from a mapping, so the first layer
+// follows the same reset rule as the later ones.
+func removeNulls(node *yaml.Node) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return node
+	}
+
+	cleaned := *node
+	cleaned.Content = nil
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if isNull(node.Content[i+1]) {
+			continue
+		}
+		cleaned.Content = append(cleaned.Content, node.Content[i], removeNulls(node.Content[i+1]))
+	}
+	return &cleaned
+}
+
+func isNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null"
+}
+
+// mappingIndex returns the position of key in a mapping node's content, or -1.
+func mappingIndex(node *yaml.Node, key string) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,92 @@
+package configuration
+
+import (
+	"gopkg.in/yaml.v3"
+	"reflect"
+	"testing"
+)
+
+type mergeTestConfiguration struct {
+	Name    string            `yaml:"name"`
+	Hosts   []string          `yaml:"hosts"`
+	Plugins []string          `yaml:"plugins" merge:"append"`
+	Labels  map[string]string `yaml:"labels"`
+	Headers map[string]string `yaml:"headers" merge:"replace"`
+	Server  struct {
+		Port    int `yaml:"port"`
+		Timeout int `yaml:"timeout"`
+	} `yaml:"server"`
+	Pools map[string]struct {
+		Hosts []string `yaml:"hosts" merge:"append"`
+	} `yaml:"pools"`
+}
+
+func TestMergeNodes_Strategies(t *testing.T) {
+	layers := []string{`
+name: base
+hosts: [a, b]
+plugins: [metrics]
+labels: {team: core, tier: backend}
+headers: {X-A: "1", X-B: "2"}
+server: {port: 8080, timeout: 30}
+pools:
+  primary:
+    hosts: [db1]
+`, `
+hosts: [c]
+plugins: [tracing]
+labels: {tier: frontend}
+headers: {X-C: "3"}
+server:
+  timeout: ~
+pools:
+  primary:
+    hosts: [db2]
+`}
+
+	rules := newMergeRules(reflect.TypeOf(mergeTestConfiguration{}))
+	root := mustParse(t, layers[0])
+	for _, layer := range layers[1:] {
+		root = mergeNodes(root, mustParse(t, layer), rules)
+	}
+
+	var got mergeTestConfiguration
+	if err := root.Decode(&got); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{name: "scalar kept", got: got.Name, want: "base"},
+		{name: "sequence replaced by default", got: got.Hosts, want: []string{"c"}},
+		{name: "sequence appended", got: got.Plugins, want: []string{"metrics", "tracing"}},
+		{name: "map merged by default", got: got.Labels, want: map[string]string{"team": "core", "tier": "frontend"}},
+		{name: "map replaced", got: got.Headers, want: map[string]string{"X-C": "3"}},
+		{name: "struct merged", got: got.Server.Port, want: 8080},
+		{name: "null resets to zero value", got: got.Server.Timeout, want: 0},
+		{name: "map element rules", got: got.Pools["primary"].Hosts, want: []string{"db1", "db2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+
+	if newEnvironment(root, nil).IsSet("server.timeout") {
+		t.Errorf("IsSet(server.timeout) = true after reset, want false")
+	}
+}
+
+func mustParse(t *testing.T, input string) *yaml.Node {
+	t.Helper()
+	root, err := parseSingleDocument("test", []byte(input))
+	if err != nil {
+		t.Fatalf("parseSingleDocument() error = %v", err)
+	}
+	return root
+}
@@ -0,0 +1,245 @@
+package configuration
+
+import (
+	"encoding"
+	"encoding/json"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Metadata describes the keys of a configuration type in the layout of
+// Spring's configuration metadata, for documentation and IDE tooling.
+type Metadata struct {
+	Groups     []GroupMetadata    `json:"groups"`
+	Properties []PropertyMetadata `json:"properties"`
+}
+
+type GroupMetadata struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+}
+
+type PropertyMetadata struct {
+	Name         string       `json:"name"`
+	Type         string       `json:"type"`
+	Description  string       `json:"description,omitempty"`
+	DefaultValue interface{}  `json:"defaultValue,omitempty"`
+	Deprecation  *Deprecation `json:"deprecation,omitempty"`
+
+	defaultValue string
+}
+
+type Deprecation struct {
+	Replacement string `json:"replacement,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// GenerateMetadata collects the `description`, `default` and `deprecated`
+// tags of T. A deprecated tag of the form "use server.address" names the
+// replacement key; any other text is kept as the reason.
+func GenerateMetadata[T any]() Metadata {
+	return generateMetadata(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+func WriteMetadata[T any](w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(GenerateMetadata[T]())
+}
+
+func WriteMetadataFile[T any](path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return WriteMetadata[T](file)
+}
+
+func generateMetadata(typ reflect.Type) Metadata {
+	metadata := Metadata{
+		Groups:     []GroupMetadata{},
+		Properties: []PropertyMetadata{},
+	}
+	collectMetadata(&metadata, typ, "", map[reflect.Type]bool{})
+	return metadata
+}
+
+func collectMetadata(metadata *Metadata, typ reflect.Type, prefix string, visiting map[reflect.Type]bool) {
+	typ = indirectType(typ)
+	if typ.Kind() != reflect.Struct || visiting[typ] {
+		return
+	}
+	visiting[typ] = true
+	defer delete(visiting, typ)
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, inline := yamlFieldName(field)
+		if name == "-" {
+			continue
+		}
+		if inline {
+			collectMetadata(metadata, field.Type, prefix, visiting)
+			continue
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		fieldType := indirectType(field.Type)
+		if isGroupType(fieldType) {
+			metadata.Groups = append(metadata.Groups, GroupMetadata{
+				Name:        name,
+				Type:        fieldType.String(),
+				Description: field.Tag.Get("description"),
+			})
+			collectMetadata(metadata, fieldType, name, visiting)
+			continue
+		}
+
+		property := PropertyMetadata{
+			Name:         name,
+			Type:         field.Type.String(),
+			Description:  field.Tag.Get("description"),
+			Deprecation:  parseDeprecation(field.Tag),
+			defaultValue: field.Tag.Get("default"),
+		}
+		if property.defaultValue != "" {
+			var value interface{}
+			if err := yaml.Unmarshal([]byte(property.defaultValue), &value); err == nil {
+				property.DefaultValue = value
+			}
+		}
+		metadata.Properties = append(metadata.Properties, property)
+
+		// Describe the fields of structs held in maps and lists as well.
+		switch fieldType.Kind() {
+		case reflect.Map:
+			if isGroupType(indirectType(fieldType.Elem())) {
+				collectMetadata(metadata, fieldType.Elem(), name+".*", visiting)
+			}
+		case reflect.Slice, reflect.Array:
+			if isGroupType(indirectType(fieldType.Elem())) {
+				collectMetadata(metadata, fieldType.Elem(), name+"[*]", visiting)
+			}
+		}
+	}
+}
+
+func parseDeprecation(tag reflect.StructTag) *Deprecation {
+	value, ok := tag.Lookup("deprecated")
+	if !ok {
+		return nil
+	}
+
+	value = strings.TrimSpace(value)
+	if replacement, found := strings.CutPrefix(value, "use "); found && !strings.ContainsAny(strings.TrimSpace(replacement), " \t") {
+		return &Deprecation{Replacement: strings.TrimSpace(replacement)}
+	}
+	return &Deprecation{Reason: value}
+}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	yamlUnmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// isGroupType reports whether typ is a struct whose fields are keys of their own.
+func isGroupType(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Struct || typ == timeType {
+		return false
+	}
+	pointer := reflect.PointerTo(typ)
+	return !pointer.Implements(yamlUnmarshalerType) && !pointer.Implements(textUnmarshalerType)
+}
+
+func indirectType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+// defaultsNode builds the lowest configuration layer from `default` tags.
+func (m Metadata) defaultsNode() *yaml.Node {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	for _, property := range m.Properties {
+		if property.defaultValue == "" || strings.Contains(property.Name, "*") {
+			continue
+		}
+		setNode(root, property.Name, &yaml.Node{Kind: yaml.ScalarNode, Value: property.defaultValue})
+	}
+	if len(root.Content) == 0 {
+		return nil
+	}
+	return root
+}
+
+// applyDeprecations warns about deprecated keys that are set and copies their
+// value to the replacement key unless that key is set as well. Values that
+// only come from `default` tags do not count as set.
+func (m Metadata) applyDeprecations(root *yaml.Node, origins nodeOrigins, rules *mergeRules, warn func(format string, args ...interface{})) *yaml.Node {
+	for _, property := range m.Properties {
+		if property.Deprecation == nil || strings.Contains(property.Name, "*") {
+			continue
+		}
+		value := lookupNode(root, property.Name)
+		if !origins.setExplicitly(value) {
+			continue
+		}
+
+		replacement := property.Deprecation.Replacement
+		if replacement == "" {
+			warn("configuration key '%s' is deprecated: %s", property.Name, property.Deprecation.Reason)
+			continue
+		}
+		warn("configuration key '%s' is deprecated, use '%s' instead", property.Name, replacement)
+		if origins.setExplicitly(lookupNode(root, replacement)) {
+			continue
+		}
+
+		overlay := &yaml.Node{Kind: yaml.MappingNode}
+		setNode(overlay, replacement, value)
+		root = mergeNodes(root, overlay, rules)
+	}
+	return root
+}
+
+// setNode stores value at the dotted key, creating intermediate mappings.
+func setNode(root *yaml.Node, key string, value *yaml.Node) {
+	parts := strings.Split(key, ".")
+	node := root
+	for _, part := range parts[:len(parts)-1] {
+		index := mappingIndex(node, part)
+		if index < 0 || node.Content[index+1].Kind != yaml.MappingNode {
+			child := &yaml.Node{Kind: yaml.MappingNode}
+			if index < 0 {
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: part}, child)
+			} else {
+				node.Content[index+1] = child
+			}
+			node = child
+			continue
+		}
+		node = node.Content[index+1]
+	}
+
+	last := parts[len(parts)-1]
+	if index := mappingIndex(node, last); index >= 0 {
+		node.Content[index+1] = value
+		return
+	}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: last}, value)
+}
@@ -0,0 +1,122 @@
+package configuration
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type metadataConfiguration struct {
+	Server struct {
+		Address string        `yaml:"address" description:"Address the server binds to"`
+		Host    string        `yaml:"host" deprecated:"use server.address"`
+		Port    int           `yaml:"port" description:"Server port" default:"8080"`
+		Timeout time.Duration `yaml:"timeout" default:"30s"`
+		Legacy  bool          `yaml:"legacy" deprecated:"no longer used"`
+	} `yaml:"server" description:"HTTP server settings"`
+	Pools map[string]struct {
+		Size int `yaml:"size"`
+	} `yaml:"pools"`
+}
+
+func TestGenerateMetadata(t *testing.T) {
+	metadata := GenerateMetadata[metadataConfiguration]()
+
+	if len(metadata.Groups) != 1 || metadata.Groups[0].Name != "server" || metadata.Groups[0].Description != "HTTP server settings" {
+		t.Errorf("Groups = %+v, want the server group", metadata.Groups)
+	}
+
+	properties := map[string]PropertyMetadata{}
+	for _, property := range metadata.Properties {
+		properties[property.Name] = property
+	}
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{name: "description", got: properties["server.address"].Description, want: "Address the server binds to"},
+		{name: "type", got: properties["server.port"].Type, want: "int"},
+		{name: "typed default", got: properties["server.port"].DefaultValue, want: 8080},
+		{name: "string default", got: properties["server.timeout"].DefaultValue, want: "30s"},
+		{name: "replacement", got: properties["server.host"].Deprecation, want: &Deprecation{Replacement: "server.address"}},
+		{name: "reason", got: properties["server.legacy"].Deprecation, want: &Deprecation{Reason: "no longer used"}},
+		{name: "map of structs", got: properties["pools.*.size"].Type, want: "int"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+
+	var buffer bytes.Buffer
+	if err := WriteMetadata[metadataConfiguration](&buffer); err != nil {
+		t.Fatalf("WriteMetadata() error = %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(buffer.Bytes(), &decoded); err != nil {
+		t.Fatalf("WriteMetadata() wrote invalid JSON: %v", err)
+	}
+	if !strings.Contains(buffer.String(), `"replacement": "server.address"`) {
+		t.Errorf("WriteMetadata() = %s, want the deprecation replacement", buffer.String())
+	}
+}
+
+func TestConfiguration_DeprecatedKeysAndDefaults(t *testing.T) {
+	var logs bytes.Buffer
+	config, err := NewConfigurationWithOptions[metadataConfiguration](layeredFs, "deprecated", Options{
+		IgnoreWorkingDir: true,
+		Logger:           log.New(&logs, "", 0),
+	})
+	if err != nil {
+		t.Fatalf("NewConfigurationWithOptions() error = %v", err)
+	}
+
+	server := config.GetConfiguration().Server
+	if server.Address != "legacy.example" {
+		t.Errorf("server.address = %q, want the value of the deprecated server.host", server.Address)
+	}
+	if server.Port != 9090 || server.Timeout != 30*time.Second {
+		t.Errorf("server = %+v, want port from the file and timeout from its default", server)
+	}
+	if !strings.Contains(logs.String(), "configuration key 'server.host' is deprecated, use 'server.address' instead") {
+		t.Errorf("log = %q, want a deprecation warning", logs.String())
+	}
+	if got := config.GetEnvironment().GetString("server.address"); got != "legacy.example" {
+		t.Errorf("environment server.address = %q, want legacy.example", got)
+	}
+}
+
+func TestConfiguration_DeprecatedKeyOverDefaultReplacement(t *testing.T) {
+	type deprecatedConfiguration struct {
+		Server struct {
+			Address string `yaml:"address" default:"0.0.0.0"`
+			Host    string `yaml:"host" deprecated:"use server.address"`
+			Mode    string `yaml:"mode" default:"legacy" deprecated:"no longer used"`
+		} `yaml:"server"`
+	}
+
+	var logs bytes.Buffer
+	config, err := NewConfigurationWithOptions[deprecatedConfiguration](nil, "test", Options{
+		PropertySources:  []PropertySource{NewMapPropertySource("test", map[string]interface{}{"server.host": "legacy.example"})},
+		IgnoreWorkingDir: true,
+		Logger:           log.New(&logs, "", 0),
+	})
+	if err != nil {
+		t.Fatalf("NewConfigurationWithOptions() error = %v", err)
+	}
+
+	if got := config.GetConfiguration().Server.Address; got != "legacy.example" {
+		t.Errorf("server.address = %q, want the deprecated value over the default", got)
+	}
+	if strings.Contains(logs.String(), "server.mode") {
+		t.Errorf("log = %q, want no warning for a deprecated key that only has its default", logs.String())
+	}
+}
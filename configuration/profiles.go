@@ -0,0 +1,264 @@
+package configuration
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// profileGroupKey holds groups such as "profiles.group.prod: [prod-db, monitoring]".
+	profileGroupKey = "profiles.group"
+	// profileGuardKey restricts a YAML document to the profiles matching an expression.
+	profileGuardKey = "config.activate.on-profile"
+)
+
+// ProfileExpression is a parsed expression such as "prod & !kr" or
+// "(dev | local) & !ci". Operators bind in the order !, &, |.
+type ProfileExpression interface {
+	Matches(profiles []string) bool
+}
+
+// ParseProfileExpression parses a profile expression. A plain profile name is
+// the simplest expression.
+func ParseProfileExpression(expression string) (ProfileExpression, error) {
+	p := &profileParser{input: expression}
+	p.next()
+	if p.token == "" {
+		return nil, fmt.Errorf("empty profile expression")
+	}
+
+	parsed, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.token != "" {
+		return nil, fmt.Errorf("unexpected %q in profile expression %q", p.token, expression)
+	}
+	return parsed, nil
+}
+
+type profileName string
+
+func (n profileName) Matches(profiles []string) bool {
+	for _, profile := range profiles {
+		if profile == string(n) {
+			return true
+		}
+	}
+	return false
+}
+
+type profileNot struct {
+	operand ProfileExpression
+}
+
+func (n profileNot) Matches(profiles []string) bool {
+	return !n.operand.Matches(profiles)
+}
+
+type profileAnd []ProfileExpression
+
+func (a profileAnd) Matches(profiles []string) bool {
+	for _, operand := range a {
+		if !operand.Matches(profiles) {
+			return false
+		}
+	}
+	return true
+}
+
+type profileOr []ProfileExpression
+
+func (o profileOr) Matches(profiles []string) bool {
+	for _, operand := range o {
+		if operand.Matches(profiles) {
+			return true
+		}
+	}
+	return false
+}
+
+type profileParser struct {
+	input    string
+	position int
+	token    string
+}
+
+func (p *profileParser) next() {
+	for p.position < len(p.input) && unicode.IsSpace(rune(p.input[p.position])) {
+		p.position++
+	}
+	if p.position >= len(p.input) {
+		p.token = ""
+		return
+	}
+
+	if strings.ContainsRune("!&|()", rune(p.input[p.position])) {
+		p.token = p.input[p.position : p.position+1]
+		p.position++
+		return
+	}
+
+	start := p.position
+	for p.position < len(p.input) && !unicode.IsSpace(rune(p.input[p.position])) && !strings.ContainsRune("!&|()", rune(p.input[p.position])) {
+		p.position++
+	}
+	p.token = p.input[start:p.position]
+}
+
+func (p *profileParser) parseOr() (ProfileExpression, error) {
+	operands := profileOr{}
+	for {
+		operand, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, operand)
+		if p.token != "|" {
+			break
+		}
+		p.next()
+	}
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return operands, nil
+}
+
+func (p *profileParser) parseAnd() (ProfileExpression, error) {
+	operands := profileAnd{}
+	for {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, operand)
+		if p.token != "&" {
+			break
+		}
+		p.next()
+	}
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return operands, nil
+}
+
+func (p *profileParser) parseUnary() (ProfileExpression, error) {
+	switch p.token {
+	case "":
+		return nil, fmt.Errorf("unexpected end of profile expression %q", p.input)
+	case "!":
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return profileNot{operand: operand}, nil
+	case "(":
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.token != ")" {
+			return nil, fmt.Errorf("missing ')' in profile expression %q", p.input)
+		}
+		p.next()
+		return inner, nil
+	case "&", "|", ")":
+		return nil, fmt.Errorf("unexpected %q in profile expression %q", p.token, p.input)
+	}
+
+	name := profileName(p.token)
+	p.next()
+	return name, nil
+}
+
+// parseProfileList splits the profile argument, which may name several
+// profiles separated by commas.
+func parseProfileList(profile string) []string {
+	var profiles []string
+	for _, name := range strings.Split(profile, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			profiles = append(profiles, name)
+		}
+	}
+	return profiles
+}
+
+// expandProfileGroups replaces every profile by itself followed by the
+// members of its group, recursively and without duplicates.
+func expandProfileGroups(profiles []string, groups map[string][]string) []string {
+	var expanded []string
+	seen := map[string]bool{}
+
+	var visit func(profile string)
+	visit = func(profile string) {
+		if seen[profile] {
+			return
+		}
+		seen[profile] = true
+		expanded = append(expanded, profile)
+		for _, member := range groups[profile] {
+			visit(member)
+		}
+	}
+
+	for _, profile := range profiles {
+		visit(profile)
+	}
+	return expanded
+}
+
+func profileGroups(root *yaml.Node) map[string][]string {
+	env := newEnvironment(root, nil)
+	group := env.lookup(profileGroupKey)
+	if group == nil || group.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	groups := map[string][]string{}
+	sub := newEnvironment(group, nil)
+	for i := 0; i+1 < len(group.Content); i += 2 {
+		name := group.Content[i].Value
+		groups[name] = sub.GetStringSlice(name)
+	}
+	return groups
+}
+
+// extractProfileGuard removes the profile guard from a document and returns it.
+func extractProfileGuard(document *yaml.Node) (string, *yaml.Node) {
+	guard := lookupNode(document, profileGuardKey)
+	if guard == nil || guard.Kind != yaml.ScalarNode {
+		return "", document
+	}
+	return guard.Value, deleteNode(document, profileGuardKey)
+}
+
+// deleteNode returns a copy of node without key, dropping mappings left empty.
+func deleteNode(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return node
+	}
+
+	cleaned := *node
+	cleaned.Content = nil
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		name, value := node.Content[i], node.Content[i+1]
+		if name.Value == key {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(key, name.Value+"."); ok && value.Kind == yaml.MappingNode {
+			value = deleteNode(value, rest)
+			if len(value.Content) == 0 {
+				continue
+			}
+		}
+		cleaned.Content = append(cleaned.Content, name, value)
+	}
+	return &cleaned
+}
@@ -0,0 +1,118 @@
+package configuration
+
+import (
+	"embed"
+	"reflect"
+	"testing"
+)
+
+//go:embed testdata/profiles
+var profilesFs embed.FS
+
+func TestParseProfileExpression(t *testing.T) {
+	tests := []struct {
+		expression string
+		profiles   []string
+		want       bool
+		wantErr    bool
+	}{
+		{expression: "prod", profiles: []string{"prod"}, want: true},
+		{expression: "prod", profiles: []string{"dev"}, want: false},
+		{expression: "prod & !kr", profiles: []string{"prod", "us"}, want: true},
+		{expression: "prod & !kr", profiles: []string{"prod", "kr"}, want: false},
+		{expression: "dev | local", profiles: []string{"local"}, want: true},
+		{expression: "dev | local", profiles: []string{"prod"}, want: false},
+		{expression: "(dev | local) & !ci", profiles: []string{"dev", "ci"}, want: false},
+		{expression: "dev | local & ci", profiles: []string{"dev"}, want: true},
+		{expression: "!!prod", profiles: []string{"prod"}, want: true},
+		{expression: "", wantErr: true},
+		{expression: "prod &", wantErr: true},
+		{expression: "(prod | dev", wantErr: true},
+		{expression: "prod dev", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			expression, err := ParseProfileExpression(tt.expression)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseProfileExpression() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := expression.Matches(tt.profiles); got != tt.want {
+				t.Errorf("Matches(%v) = %v, want %v", tt.profiles, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandProfileGroups(t *testing.T) {
+	groups := map[string][]string{
+		"prod":       {"prod-db", "prod-web", "monitoring"},
+		"monitoring": {"metrics", "prod"},
+	}
+
+	got := expandProfileGroups([]string{"prod", "kr"}, groups)
+	want := []string{"prod", "prod-db", "prod-web", "monitoring", "metrics", "kr"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expandProfileGroups() = %v, want %v", got, want)
+	}
+}
+
+type profileConfiguration struct {
+	Server struct {
+		Region string `yaml:"region"`
+		Locale string `yaml:"locale"`
+	} `yaml:"server"`
+	Database struct {
+		URL string `yaml:"url"`
+	} `yaml:"database"`
+	Monitoring struct {
+		Enabled bool `yaml:"enabled"`
+	} `yaml:"monitoring"`
+}
+
+func TestConfiguration_ProfileGroupsAndGuards(t *testing.T) {
+	tests := []struct {
+		name         string
+		profile      string
+		wantProfiles []string
+		wantRegion   string
+		wantLocale   string
+	}{
+		{name: "group expanded", profile: "prod", wantProfiles: []string{"prod", "prod-db", "monitoring"}, wantRegion: "us"},
+		{name: "several profiles", profile: "prod, kr", wantProfiles: []string{"prod", "prod-db", "monitoring", "kr"}, wantRegion: "kr", wantLocale: "ko"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := NewConfigurationWithOptions[profileConfiguration](profilesFs, tt.profile, Options{
+				EmbedSearchRoots: []string{"testdata/profiles"},
+				IgnoreWorkingDir: true,
+			})
+			if err != nil {
+				t.Fatalf("NewConfigurationWithOptions() error = %v", err)
+			}
+
+			env := config.GetEnvironment()
+			if !reflect.DeepEqual(env.ActiveProfiles(), tt.wantProfiles) {
+				t.Errorf("ActiveProfiles() = %v, want %v", env.ActiveProfiles(), tt.wantProfiles)
+			}
+
+			got := config.GetConfiguration()
+			if got.Server.Region != tt.wantRegion || got.Server.Locale != tt.wantLocale {
+				t.Errorf("server = %+v, want region %q and locale %q", got.Server, tt.wantRegion, tt.wantLocale)
+			}
+			if got.Database.URL == "" || !got.Monitoring.Enabled {
+				t.Errorf("group member files were not loaded: %+v", got)
+			}
+			if env.IsSet(profileGuardKey) || env.IsSet("config") {
+				t.Errorf("profile guard leaked into the environment")
+			}
+
+			accepted, err := config.AcceptsProfiles("prod-db & monitoring")
+			if err != nil || !accepted {
+				t.Errorf("AcceptsProfiles() = %v, %v, want true", accepted, err)
+			}
+		})
+	}
+}
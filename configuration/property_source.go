@@ -0,0 +1,141 @@
+package configuration
+
+import (
+	"io/fs"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PropertySource supplies one layer of YAML configuration. Sources are merged
+// in order, so a later source overrides the keys of an earlier one. A source
+// that does not exist should return an error wrapping fs.ErrNotExist; it is
+// then skipped.
+type PropertySource interface {
+	Name() string
+	Read() ([]byte, error)
+}
+
+type FilePropertySource struct {
+	Path string
+}
+
+func NewFilePropertySource(path string) *FilePropertySource {
+	return &FilePropertySource{Path: path}
+}
+
+func (s *FilePropertySource) Name() string {
+	return s.Path
+}
+
+func (s *FilePropertySource) Read() ([]byte, error) {
+	return os.ReadFile(s.Path)
+}
+
+type FSPropertySource struct {
+	FS   fs.FS
+	Path string
+}
+
+func NewFSPropertySource(fsys fs.FS, path string) *FSPropertySource {
+	return &FSPropertySource{FS: fsys, Path: path}
+}
+
+func (s *FSPropertySource) Name() string {
+	return "embed:" + s.Path
+}
+
+func (s *FSPropertySource) Read() ([]byte, error) {
+	return fs.ReadFile(s.FS, s.Path)
+}
+
+// MapPropertySource holds properties keyed by flat names such as
+// "server.port" or "servers[0].host", which are expanded into nested YAML.
+type MapPropertySource struct {
+	SourceName string
+	Properties map[string]interface{}
+}
+
+func NewMapPropertySource(name string, properties map[string]interface{}) *MapPropertySource {
+	return &MapPropertySource{SourceName: name, Properties: properties}
+}
+
+func (s *MapPropertySource) Name() string {
+	return s.SourceName
+}
+
+func (s *MapPropertySource) Read() ([]byte, error) {
+	return yaml.Marshal(expandProperties(s.Properties))
+}
+
+func expandProperties(properties map[string]interface{}) map[string]interface{} {
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	expanded := map[string]interface{}{}
+	for _, key := range keys {
+		expanded = insertProperty(expanded, splitPropertyKey(key), properties[key]).(map[string]interface{})
+	}
+	return expanded
+}
+
+type propertyKeyToken struct {
+	name  string
+	index int
+}
+
+// splitPropertyKey turns "a.b[0].c" into the tokens a, b, [0], c.
+func splitPropertyKey(key string) []propertyKeyToken {
+	var tokens []propertyKeyToken
+	for _, part := range strings.Split(key, ".") {
+		name := part
+		var indexes []int
+		for strings.HasSuffix(name, "]") {
+			open := strings.LastIndex(name, "[")
+			if open < 0 {
+				break
+			}
+			index, err := strconv.Atoi(name[open+1 : len(name)-1])
+			if err != nil || index < 0 {
+				break
+			}
+			indexes = append([]int{index}, indexes...)
+			name = name[:open]
+		}
+
+		tokens = append(tokens, propertyKeyToken{name: name, index: -1})
+		for _, index := range indexes {
+			tokens = append(tokens, propertyKeyToken{index: index})
+		}
+	}
+	return tokens
+}
+
+func insertProperty(container interface{}, tokens []propertyKeyToken, value interface{}) interface{} {
+	if len(tokens) == 0 {
+		return value
+	}
+
+	token := tokens[0]
+	if token.index < 0 {
+		m, ok := container.(map[string]interface{})
+		if !ok {
+			m = map[string]interface{}{}
+		}
+		m[token.name] = insertProperty(m[token.name], tokens[1:], value)
+		return m
+	}
+
+	s, _ := container.([]interface{})
+	for len(s) <= token.index {
+		s = append(s, nil)
+	}
+	s[token.index] = insertProperty(s[token.index], tokens[1:], value)
+	return s
+}
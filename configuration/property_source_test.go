@@ -0,0 +1,44 @@
+package configuration
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMapPropertySource_Read(t *testing.T) {
+	source := NewMapPropertySource("test", map[string]interface{}{
+		"server.port":          8080,
+		"server.host":          "localhost",
+		"servers[0].name":      "a",
+		"servers[1].name":      "b",
+		"matrix[0][0]":         "x",
+		"cors.allowed-origins": []string{"*"},
+	})
+
+	bytes, err := source.Read()
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	root, err := parseSingleDocument("test", bytes)
+	if err != nil {
+		t.Fatalf("parseSingleDocument() error = %v", err)
+	}
+	env := newEnvironment(root, nil)
+
+	if got := env.GetInt("server.port"); got != 8080 {
+		t.Errorf("server.port = %v, want 8080", got)
+	}
+	var servers []struct {
+		Name string `yaml:"name"`
+	}
+	if err := env.Bind("servers", &servers); err != nil || len(servers) != 2 || servers[1].Name != "b" {
+		t.Errorf("servers = %v (err %v), want two entries", servers, err)
+	}
+	var matrix [][]string
+	if err := env.Bind("matrix", &matrix); err != nil || !reflect.DeepEqual(matrix, [][]string{{"x"}}) {
+		t.Errorf("matrix = %v (err %v), want [[x]]", matrix, err)
+	}
+	if got := env.GetStringSlice("cors.allowed-origins"); !reflect.DeepEqual(got, []string{"*"}) {
+		t.Errorf("cors.allowed-origins = %v, want [*]", got)
+	}
+}
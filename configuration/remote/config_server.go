@@ -0,0 +1,299 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zbum/mantyboot/configuration"
+	"github.com/zbum/mantyboot/errors"
+)
+
+// ConfigServerConfig describes how to reach a Spring Cloud Config compatible server.
+type ConfigServerConfig struct {
+	URI         string
+	Application string
+	Profile     string
+	Label       string
+	Username    string
+	Password    string
+	Token       string
+
+	Timeout         time.Duration
+	MaxAttempts     int
+	InitialInterval time.Duration
+	Multiplier      float64
+	MaxInterval     time.Duration
+
+	// CacheFile stores the last successful response, used when the server is down.
+	CacheFile string
+
+	HTTPClient *http.Client
+	Logger     *log.Logger
+}
+
+// DefaultConfigServerConfig leaves Application empty; it must be set.
+func DefaultConfigServerConfig() *ConfigServerConfig {
+	return &ConfigServerConfig{
+		URI:             "http://localhost:8888",
+		Profile:         "default",
+		Timeout:         10 * time.Second,
+		MaxAttempts:     6,
+		InitialInterval: time.Second,
+		Multiplier:      1.1,
+		MaxInterval:     2 * time.Second,
+	}
+}
+
+type environmentResponse struct {
+	Name            string   `json:"name"`
+	Profiles        []string `json:"profiles"`
+	Label           string   `json:"label"`
+	Version         string   `json:"version"`
+	PropertySources []struct {
+		Name   string                 `json:"name"`
+		Source map[string]interface{} `json:"source"`
+	} `json:"propertySources"`
+}
+
+// ConfigServerPropertySource fetches /{application}/{profile}[/{label}] from a
+// config server. The response is fetched once at construction and then only
+// on Refresh, so reloading the configuration does not hit the network.
+type ConfigServerPropertySource struct {
+	config *ConfigServerConfig
+	client *http.Client
+
+	mu      sync.RWMutex
+	content []byte
+}
+
+func NewConfigServerPropertySource(config *ConfigServerConfig) (*ConfigServerPropertySource, error) {
+	if config == nil {
+		config = DefaultConfigServerConfig()
+	}
+	if config.Application == "" {
+		return nil, errors.WrapConfigurationError(nil, "config server application name is required")
+	}
+
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: config.Timeout}
+	}
+
+	s := &ConfigServerPropertySource{
+		config: config,
+		client: client,
+	}
+
+	body, err := s.fetchWithRetry(context.Background())
+	if err != nil {
+		cached, cacheErr := s.readCache()
+		if cacheErr != nil {
+			return nil, errors.WrapConfigurationError(err, "failed to fetch configuration from "+s.endpoint())
+		}
+		s.logf("config server %s is unavailable, using cache %s: %v", s.endpoint(), config.CacheFile, err)
+		body = cached
+	} else {
+		s.writeCache(body)
+	}
+
+	if err := s.update(body); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *ConfigServerPropertySource) Name() string {
+	return "configserver:" + s.endpoint()
+}
+
+func (s *ConfigServerPropertySource) Read() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.content, nil
+}
+
+// Refresh fetches the configuration again and reports whether it changed.
+func (s *ConfigServerPropertySource) Refresh(ctx context.Context) (bool, error) {
+	body, err := s.fetch(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.RLock()
+	previous := s.content
+	s.mu.RUnlock()
+
+	if err := s.update(body); err != nil {
+		return false, err
+	}
+	s.writeCache(body)
+
+	current, _ := s.Read()
+	return !bytes.Equal(previous, current), nil
+}
+
+// Reloader is implemented by configuration.Configuration.
+type Reloader interface {
+	Reload() error
+}
+
+// StartRefresh calls Refresh every interval until ctx is done, and reloads
+// the configuration whenever the server returned different properties.
+func (s *ConfigServerPropertySource) StartRefresh(ctx context.Context, interval time.Duration, reloader Reloader) error {
+	if interval <= 0 {
+		return errors.WrapConfigurationError(nil, fmt.Sprintf("invalid config server refresh interval %s", interval))
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				changed, err := s.Refresh(ctx)
+				if err != nil {
+					s.logf("failed to refresh configuration from %s: %v", s.endpoint(), err)
+					continue
+				}
+				if !changed {
+					continue
+				}
+				if err := reloader.Reload(); err != nil {
+					s.logf("failed to reload configuration: %v", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *ConfigServerPropertySource) update(body []byte) error {
+	var response environmentResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return errors.WrapConfigurationError(err, "invalid config server response")
+	}
+
+	// Property sources are listed from highest to lowest precedence.
+	properties := map[string]interface{}{}
+	for i := len(response.PropertySources) - 1; i >= 0; i-- {
+		for key, value := range response.PropertySources[i].Source {
+			properties[key] = value
+		}
+	}
+
+	content, err := configuration.NewMapPropertySource(s.Name(), properties).Read()
+	if err != nil {
+		return errors.WrapConfigurationError(err, "failed to convert config server properties")
+	}
+
+	s.mu.Lock()
+	s.content = content
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *ConfigServerPropertySource) fetchWithRetry(ctx context.Context) ([]byte, error) {
+	attempts := s.config.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	interval := s.config.InitialInterval
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		var body []byte
+		body, err = s.fetch(ctx)
+		if err == nil {
+			return body, nil
+		}
+		// Transport failures and server side errors are retried, 4xx responses are not.
+		if errors.IsClientError(err) || attempt == attempts {
+			break
+		}
+
+		s.logf("config server request failed (attempt %d/%d): %v", attempt, attempts, err)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+
+		interval = time.Duration(float64(interval) * s.config.Multiplier)
+		if s.config.MaxInterval > 0 && interval > s.config.MaxInterval {
+			interval = s.config.MaxInterval
+		}
+	}
+	return nil, err
+}
+
+func (s *ConfigServerPropertySource) fetch(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.endpoint(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if s.config.Username != "" {
+		req.SetBasicAuth(s.config.Username, s.config.Password)
+	}
+	if s.config.Token != "" {
+		req.Header.Set("X-Config-Token", s.config.Token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.WrapHTTPError(nil, resp.StatusCode, "unexpected config server response")
+	}
+	return body, nil
+}
+
+func (s *ConfigServerPropertySource) endpoint() string {
+	segments := []string{strings.TrimRight(s.config.URI, "/"), url.PathEscape(s.config.Application), url.PathEscape(s.config.Profile)}
+	if s.config.Label != "" {
+		segments = append(segments, url.PathEscape(s.config.Label))
+	}
+	return strings.Join(segments, "/")
+}
+
+func (s *ConfigServerPropertySource) readCache() ([]byte, error) {
+	if s.config.CacheFile == "" {
+		return nil, fmt.Errorf("no cache file configured")
+	}
+	return os.ReadFile(s.config.CacheFile)
+}
+
+func (s *ConfigServerPropertySource) writeCache(body []byte) {
+	if s.config.CacheFile == "" {
+		return
+	}
+	if err := os.WriteFile(s.config.CacheFile, body, 0o600); err != nil {
+		s.logf("failed to write config cache %s: %v", s.config.CacheFile, err)
+	}
+}
+
+func (s *ConfigServerPropertySource) logf(format string, args ...interface{}) {
+	if s.config.Logger != nil {
+		s.config.Logger.Printf(format, args...)
+	}
+}
@@ -0,0 +1,201 @@
+package remote
+
+import (
+	"context"
+	"embed"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zbum/mantyboot/configuration"
+)
+
+type remoteConfiguration struct {
+	Server struct {
+		Host string `yaml:"host"`
+		Port int    `yaml:"port"`
+	} `yaml:"server"`
+	Hosts []string `yaml:"hosts"`
+}
+
+const remoteResponse = `{
+  "name": "orders",
+  "profiles": ["dev"],
+  "propertySources": [
+    {"name": "orders-dev.yml", "source": {"server.port": 9090, "hosts[0]": "a", "hosts[1]": "b"}},
+    {"name": "orders.yml", "source": {"server.port": 8080, "server.host": "localhost"}}
+  ]
+}`
+
+type stubServer struct {
+	mu       sync.Mutex
+	body     string
+	failures int32
+	requests int32
+}
+
+func (s *stubServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&s.requests, 1)
+	if r.URL.Path != "/orders/dev" {
+		http.NotFound(w, r)
+		return
+	}
+	if atomic.AddInt32(&s.failures, -1) >= 0 {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(s.body))
+}
+
+func (s *stubServer) setBody(body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body = body
+}
+
+func testConfig(uri string) *ConfigServerConfig {
+	config := DefaultConfigServerConfig()
+	config.URI = uri
+	config.Application = "orders"
+	config.Profile = "dev"
+	config.MaxAttempts = 3
+	config.InitialInterval = time.Millisecond
+	config.MaxInterval = 5 * time.Millisecond
+	return config
+}
+
+func loadConfiguration(t *testing.T, source configuration.PropertySource) *configuration.Configuration[remoteConfiguration] {
+	t.Helper()
+	config, err := configuration.NewConfigurationWithOptions[remoteConfiguration](embed.FS{}, "dev", configuration.Options{
+		PropertySources:  []configuration.PropertySource{source},
+		IgnoreWorkingDir: true,
+	})
+	if err != nil {
+		t.Fatalf("NewConfigurationWithOptions() error = %v", err)
+	}
+	return config
+}
+
+func TestConfigServerPropertySource_Fetch(t *testing.T) {
+	stub := &stubServer{body: remoteResponse, failures: 2}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	source, err := NewConfigServerPropertySource(testConfig(server.URL))
+	if err != nil {
+		t.Fatalf("NewConfigServerPropertySource() error = %v", err)
+	}
+	if got := atomic.LoadInt32(&stub.requests); got != 3 {
+		t.Errorf("requests = %d, want 3 (two retries)", got)
+	}
+
+	got := loadConfiguration(t, source).GetConfiguration()
+	if got.Server.Port != 9090 || got.Server.Host != "localhost" {
+		t.Errorf("server = %+v, want port 9090 from the profile source and host from the default source", got.Server)
+	}
+	if len(got.Hosts) != 2 || got.Hosts[1] != "b" {
+		t.Errorf("hosts = %v, want [a b]", got.Hosts)
+	}
+}
+
+func TestConfigServerPropertySource_NotRetryingClientErrors(t *testing.T) {
+	stub := &stubServer{body: remoteResponse}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	config := testConfig(server.URL)
+	config.Application = "unknown"
+	if _, err := NewConfigServerPropertySource(config); err == nil {
+		t.Fatalf("NewConfigServerPropertySource() error = nil, want error for 404")
+	}
+	if got := atomic.LoadInt32(&stub.requests); got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+}
+
+func TestConfigServerPropertySource_RequiresApplication(t *testing.T) {
+	stub := &stubServer{body: remoteResponse}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	config := testConfig(server.URL)
+	config.Application = ""
+	if _, err := NewConfigServerPropertySource(config); err == nil {
+		t.Fatalf("NewConfigServerPropertySource() error = nil, want error without an application")
+	}
+	if got := atomic.LoadInt32(&stub.requests); got != 0 {
+		t.Errorf("requests = %d, want 0", got)
+	}
+}
+
+func TestConfigServerPropertySource_CacheFallback(t *testing.T) {
+	cacheFile := filepath.Join(t.TempDir(), "orders-dev.json")
+
+	server := httptest.NewServer(&stubServer{body: remoteResponse})
+	config := testConfig(server.URL)
+	config.CacheFile = cacheFile
+	if _, err := NewConfigServerPropertySource(config); err != nil {
+		t.Fatalf("NewConfigServerPropertySource() error = %v", err)
+	}
+	server.Close()
+
+	source, err := NewConfigServerPropertySource(config)
+	if err != nil {
+		t.Fatalf("NewConfigServerPropertySource() with cache error = %v", err)
+	}
+	if got := loadConfiguration(t, source).GetConfiguration(); got.Server.Port != 9090 {
+		t.Errorf("server.port = %d, want 9090 from cache", got.Server.Port)
+	}
+
+	config.CacheFile = filepath.Join(t.TempDir(), "missing.json")
+	if _, err := NewConfigServerPropertySource(config); err == nil {
+		t.Errorf("NewConfigServerPropertySource() error = nil, want error without server and cache")
+	}
+}
+
+func TestConfigServerPropertySource_Refresh(t *testing.T) {
+	stub := &stubServer{body: remoteResponse}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	source, err := NewConfigServerPropertySource(testConfig(server.URL))
+	if err != nil {
+		t.Fatalf("NewConfigServerPropertySource() error = %v", err)
+	}
+	config := loadConfiguration(t, source)
+
+	reloaded := make(chan int, 1)
+	config.OnReload(func(c *remoteConfiguration) {
+		reloaded <- c.Server.Port
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := source.StartRefresh(ctx, 0, config); err == nil {
+		t.Errorf("StartRefresh() with a zero interval succeeded")
+	}
+	if err := source.StartRefresh(ctx, 10*time.Millisecond, config); err != nil {
+		t.Fatalf("StartRefresh() error = %v", err)
+	}
+
+	stub.setBody(`{"propertySources": [{"name": "orders.yml", "source": {"server.port": 7070}}]}`)
+
+	select {
+	case port := <-reloaded:
+		if port != 7070 {
+			t.Errorf("reloaded server.port = %d, want 7070", port)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("configuration was not reloaded after the server changed")
+	}
+	if got := config.GetEnvironment().GetInt("server.port"); got != 7070 {
+		t.Errorf("environment server.port = %d, want 7070", got)
+	}
+}
@@ -63,22 +63,27 @@ func (cv *ConfigurationValidator) Validate(config interface{}) error {
 	}
 
 	if len(validationErrors) > 0 {
-		return &errors.ValidationError{
-			Field:   "configuration",
-			Message: fmt.Sprintf("validation failed: %v", validationErrors),
-		}
+		return errors.NewAggregateValidationError("configuration", fmt.Sprintf("validation failed: %v", validationErrors), violations(validationErrors))
 	}
 
 	return nil
 }
 
+// violations collects the field failures of an aggregated validation.
+func violations(validationErrors []error) []errors.ValidationError {
+	var result []errors.ValidationError
+	for _, err := range validationErrors {
+		if validationErr, ok := err.(*errors.ValidationError); ok {
+			result = append(result, *validationErr)
+		}
+	}
+	return result
+}
+
 func (cv *ConfigurationValidator) validateField(field reflect.Value, rule ValidationRule) error {
 	// Check if field is zero value when required
 	if rule.Required && field.IsZero() {
-		return &errors.ValidationError{
-			Field:   rule.Field,
-			Message: "field is required",
-		}
+		return errors.NewValidationError(rule.Field, errors.CodeRequired, nil)
 	}
 
 	// Skip validation if field is zero and not required
@@ -113,24 +118,15 @@ func (cv *ConfigurationValidator) validateField(field reflect.Value, rule Valida
 
 func (cv *ConfigurationValidator) validateString(value string, rule ValidationRule) error {
 	if rule.MinLength != nil && len(value) < *rule.MinLength {
-		return &errors.ValidationError{
-			Field:   rule.Field,
-			Message: fmt.Sprintf("length must be at least %d", *rule.MinLength),
-		}
+		return errors.NewValidationError(rule.Field, errors.CodeMinLength, map[string]interface{}{"min": *rule.MinLength})
 	}
 
 	if rule.MaxLength != nil && len(value) > *rule.MaxLength {
-		return &errors.ValidationError{
-			Field:   rule.Field,
-			Message: fmt.Sprintf("length must be at most %d", *rule.MaxLength),
-		}
+		return errors.NewValidationError(rule.Field, errors.CodeMaxLength, map[string]interface{}{"max": *rule.MaxLength})
 	}
 
 	if rule.Pattern != nil && !rule.Pattern.MatchString(value) {
-		return &errors.ValidationError{
-			Field:   rule.Field,
-			Message: fmt.Sprintf("must match pattern %s", rule.Pattern.String()),
-		}
+		return errors.NewValidationError(rule.Field, errors.CodePattern, map[string]interface{}{"pattern": rule.Pattern.String()})
 	}
 
 	return nil
@@ -138,17 +134,11 @@ func (cv *ConfigurationValidator) validateString(value string, rule ValidationRu
 
 func (cv *ConfigurationValidator) validateInt(value int64, rule ValidationRule) error {
 	if rule.Min != nil && value < int64(*rule.Min) {
-		return &errors.ValidationError{
-			Field:   rule.Field,
-			Message: fmt.Sprintf("must be at least %d", *rule.Min),
-		}
+		return errors.NewValidationError(rule.Field, errors.CodeMin, map[string]interface{}{"min": *rule.Min})
 	}
 
 	if rule.Max != nil && value > int64(*rule.Max) {
-		return &errors.ValidationError{
-			Field:   rule.Field,
-			Message: fmt.Sprintf("must be at most %d", *rule.Max),
-		}
+		return errors.NewValidationError(rule.Field, errors.CodeMax, map[string]interface{}{"max": *rule.Max})
 	}
 
 	return nil
@@ -156,17 +146,11 @@ func (cv *ConfigurationValidator) validateInt(value int64, rule ValidationRule)
 
 func (cv *ConfigurationValidator) validateUint(value uint64, rule ValidationRule) error {
 	if rule.Min != nil && value < uint64(*rule.Min) {
-		return &errors.ValidationError{
-			Field:   rule.Field,
-			Message: fmt.Sprintf("must be at least %d", *rule.Min),
-		}
+		return errors.NewValidationError(rule.Field, errors.CodeMin, map[string]interface{}{"min": *rule.Min})
 	}
 
 	if rule.Max != nil && value > uint64(*rule.Max) {
-		return &errors.ValidationError{
-			Field:   rule.Field,
-			Message: fmt.Sprintf("must be at most %d", *rule.Max),
-		}
+		return errors.NewValidationError(rule.Field, errors.CodeMax, map[string]interface{}{"max": *rule.Max})
 	}
 
 	return nil
@@ -174,17 +158,11 @@ func (cv *ConfigurationValidator) validateUint(value uint64, rule ValidationRule
 
 func (cv *ConfigurationValidator) validateFloat(value float64, rule ValidationRule) error {
 	if rule.Min != nil && value < float64(*rule.Min) {
-		return &errors.ValidationError{
-			Field:   rule.Field,
-			Message: fmt.Sprintf("must be at least %d", *rule.Min),
-		}
+		return errors.NewValidationError(rule.Field, errors.CodeMin, map[string]interface{}{"min": *rule.Min})
 	}
 
 	if rule.Max != nil && value > float64(*rule.Max) {
-		return &errors.ValidationError{
-			Field:   rule.Field,
-			Message: fmt.Sprintf("must be at most %d", *rule.Max),
-		}
+		return errors.NewValidationError(rule.Field, errors.CodeMax, map[string]interface{}{"max": *rule.Max})
 	}
 
 	return nil
@@ -250,10 +228,7 @@ func ValidateStruct(config interface{}) error {
 	}
 
 	if len(validationErrors) > 0 {
-		return &errors.ValidationError{
-			Field:   "configuration",
-			Message: fmt.Sprintf("validation failed: %v", validationErrors),
-		}
+		return errors.NewAggregateValidationError("configuration", fmt.Sprintf("validation failed: %v", validationErrors), violations(validationErrors))
 	}
 
 	return nil
@@ -274,10 +249,7 @@ func validateFieldByTags(field reflect.Value, fieldType reflect.StructField) err
 		switch {
 		case rule == "required":
 			if field.IsZero() {
-				return &errors.ValidationError{
-					Field:   fieldName,
-					Message: "field is required",
-				}
+				return errors.NewValidationError(fieldName, errors.CodeRequired, nil)
 			}
 		case strings.HasPrefix(rule, "min="):
 			if err := validateMin(field, rule, fieldName); err != nil {
@@ -307,17 +279,11 @@ func validateMin(field reflect.Value, rule, fieldName string) error {
 	switch field.Kind() {
 	case reflect.String:
 		if len(field.String()) < min {
-			return &errors.ValidationError{
-				Field:   fieldName,
-				Message: fmt.Sprintf("length must be at least %d", min),
-			}
+			return errors.NewValidationError(fieldName, errors.CodeMinLength, map[string]interface{}{"min": min})
 		}
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		if field.Int() < int64(min) {
-			return &errors.ValidationError{
-				Field:   fieldName,
-				Message: fmt.Sprintf("must be at least %d", min),
-			}
+			return errors.NewValidationError(fieldName, errors.CodeMin, map[string]interface{}{"min": min})
 		}
 	}
 
@@ -334,17 +300,11 @@ func validateMax(field reflect.Value, rule, fieldName string) error {
 	switch field.Kind() {
 	case reflect.String:
 		if len(field.String()) > max {
-			return &errors.ValidationError{
-				Field:   fieldName,
-				Message: fmt.Sprintf("length must be at most %d", max),
-			}
+			return errors.NewValidationError(fieldName, errors.CodeMaxLength, map[string]interface{}{"max": max})
 		}
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		if field.Int() > int64(max) {
-			return &errors.ValidationError{
-				Field:   fieldName,
-				Message: fmt.Sprintf("must be at most %d", max),
-			}
+			return errors.NewValidationError(fieldName, errors.CodeMax, map[string]interface{}{"max": max})
 		}
 	}
 
@@ -356,10 +316,7 @@ func validatePattern(field reflect.Value, rule, fieldName string) error {
 	pattern := regexp.MustCompile(patternStr)
 
 	if field.Kind() == reflect.String && !pattern.MatchString(field.String()) {
-		return &errors.ValidationError{
-			Field:   fieldName,
-			Message: fmt.Sprintf("must match pattern %s", patternStr),
-		}
+		return errors.NewValidationError(fieldName, errors.CodePattern, map[string]interface{}{"pattern": patternStr})
 	}
 
 	return nil
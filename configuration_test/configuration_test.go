@@ -1,10 +1,12 @@
 package configuration_test
 
 import (
-	"embed"
-	"github.com/zbum/mantyboot/configuration"
 	"reflect"
 	"testing"
+	"testing/fstest"
+
+	"github.com/zbum/mantyboot/configuration"
+	"github.com/zbum/mantyboot/configuration/configtest"
 )
 
 type TestConfiguration struct {
@@ -12,41 +14,83 @@ type TestConfiguration struct {
 	B string `yaml:"b"`
 }
 
-//go:embed embed/application-dev.yaml
-var sampleFs embed.FS
+// sampleFs stands in for the embedded files of an application. The
+// working directory files that override them are given as inline YAML.
+var sampleFs = fstest.MapFS{
+	"embed/application-dev.yaml": {Data: []byte("a:\n  aValue\nb:\n  bValue\n")},
+}
 
-func TestNewConfiguration(t *testing.T) {
-	type args struct {
-		embedDir embed.FS
-		profile  string
-	}
+func TestLoad(t *testing.T) {
 	type testCase[T any] struct {
 		name    string
-		args    args
+		fixture configtest.Fixture
 		want    *T
 		wantErr bool
 	}
 	tests := []testCase[TestConfiguration]{
 		{
 			name: "simple",
-			args: args{
-				embedDir: sampleFs,
-				profile:  "dev",
+			fixture: configtest.Fixture{
+				Profile: "dev",
+				FS:      sampleFs,
+				YAML:    []string{"b:\n  bNewValue\n"},
 			},
 			want:    &TestConfiguration{A: "aValue", B: "bNewValue"},
 			wantErr: false,
 		},
+		{
+			name:    "missing profile",
+			fixture: configtest.Fixture{Profile: "prod", FS: sampleFs},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := configuration.NewConfiguration[TestConfiguration](tt.args.embedDir, tt.args.profile)
+			got, err := configtest.Load[TestConfiguration](tt.fixture)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("NewConfiguration() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
 				return
 			}
 			if !reflect.DeepEqual(got.GetConfiguration(), tt.want) {
-				t.Errorf("NewConfiguration() got = %v, want %v", got, tt.want)
+				t.Errorf("Load() got = %v, want %v", got.GetConfiguration(), tt.want)
 			}
 		})
 	}
 }
+
+func TestNewConfigurationWithOptions(t *testing.T) {
+	got, err := configuration.NewConfigurationWithOptions[TestConfiguration](sampleFs, "dev", configuration.Options{IgnoreWorkingDir: true})
+	if err != nil {
+		t.Fatalf("NewConfigurationWithOptions() error = %v", err)
+	}
+	want := &TestConfiguration{A: "aValue", B: "bValue"}
+	if !reflect.DeepEqual(got.GetConfiguration(), want) {
+		t.Errorf("NewConfigurationWithOptions() got = %v, want %v", got.GetConfiguration(), want)
+	}
+
+	if _, err := configuration.NewConfigurationWithOptions[TestConfiguration](sampleFs, "prod", configuration.Options{IgnoreWorkingDir: true}); err == nil {
+		t.Error("NewConfigurationWithOptions() of a missing profile succeeded")
+	}
+}
+
+func TestConfiguration_GetEnvironment(t *testing.T) {
+	got := configtest.New[TestConfiguration](t, configtest.Fixture{
+		Profile: "dev",
+		FS:      sampleFs,
+		YAML:    []string{"b:\n  bNewValue\n"},
+	})
+
+	env := got.GetEnvironment()
+	if value := env.GetString("a"); value != "aValue" {
+		t.Errorf("GetString(a) = %v, want %v", value, "aValue")
+	}
+	if value := env.GetString("b"); value != "bNewValue" {
+		t.Errorf("GetString(b) = %v, want %v", value, "bNewValue")
+	}
+	if !reflect.DeepEqual(env.ActiveProfiles(), []string{"dev"}) {
+		t.Errorf("ActiveProfiles() = %v, want [dev]", env.ActiveProfiles())
+	}
+}
@@ -0,0 +1,313 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/zbum/mantyboot/data/support"
+)
+
+// DB is a *sql.DB whose statement and transaction errors are translated by the
+// translator registered for its driver.
+type DB struct {
+	*sql.DB
+	driverName string
+	translator support.PersistenceErrorTranslator
+}
+
+// Open opens a database like sql.Open and wraps it.
+func Open(driverName, dsn string) (*DB, error) {
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	return NewDB(db, driverName), nil
+}
+
+// NewDB wraps an already opened database.
+func NewDB(db *sql.DB, driverName string) *DB {
+	return &DB{
+		DB:         db,
+		driverName: driverName,
+		translator: Translator(driverName),
+	}
+}
+
+func (db *DB) DriverName() string {
+	return db.driverName
+}
+
+// Translate passes err through the translator of the database.
+func (db *DB) Translate(err error) error {
+	return translate(db.translator, err)
+}
+
+func (db *DB) PingContext(ctx context.Context) error {
+	return db.Translate(db.DB.PingContext(ctx))
+}
+
+func (db *DB) Ping() error {
+	return db.PingContext(context.Background())
+}
+
+// ExecContext runs in the transaction of ctx, if any.
+func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if tx, ok := db.TxFromContext(ctx); ok {
+		return tx.ExecContext(ctx, query, args...)
+	}
+	result, err := db.DB.ExecContext(ctx, query, args...)
+	return result, db.Translate(err)
+}
+
+func (db *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return db.ExecContext(context.Background(), query, args...)
+}
+
+// QueryContext runs in the transaction of ctx, if any.
+func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*Rows, error) {
+	if tx, ok := db.TxFromContext(ctx); ok {
+		return tx.QueryContext(ctx, query, args...)
+	}
+	rows, err := db.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, db.Translate(err)
+	}
+	return &Rows{Rows: rows, translator: db.translator}, nil
+}
+
+func (db *DB) Query(query string, args ...interface{}) (*Rows, error) {
+	return db.QueryContext(context.Background(), query, args...)
+}
+
+// QueryRowContext runs in the transaction of ctx, if any.
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *Row {
+	if tx, ok := db.TxFromContext(ctx); ok {
+		return tx.QueryRowContext(ctx, query, args...)
+	}
+	return &Row{row: db.DB.QueryRowContext(ctx, query, args...), translator: db.translator}
+}
+
+func (db *DB) QueryRow(query string, args ...interface{}) *Row {
+	return db.QueryRowContext(context.Background(), query, args...)
+}
+
+// PrepareContext prepares in the transaction of ctx, if any.
+func (db *DB) PrepareContext(ctx context.Context, query string) (*Stmt, error) {
+	if tx, ok := db.TxFromContext(ctx); ok {
+		return tx.PrepareContext(ctx, query)
+	}
+	stmt, err := db.DB.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, db.Translate(err)
+	}
+	return &Stmt{Stmt: stmt, translator: db.translator}, nil
+}
+
+func (db *DB) Prepare(query string) (*Stmt, error) {
+	return db.PrepareContext(context.Background(), query)
+}
+
+// Conn reserves a single connection. Statements on it do not join the
+// transaction of their context.
+func (db *DB) Conn(ctx context.Context) (*Conn, error) {
+	conn, err := db.DB.Conn(ctx)
+	if err != nil {
+		return nil, db.Translate(err)
+	}
+	return &Conn{Conn: conn, translator: db.translator}, nil
+}
+
+func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	tx, err := db.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, db.Translate(err)
+	}
+	return &Tx{Tx: tx, translator: db.translator}, nil
+}
+
+func (db *DB) Begin() (*Tx, error) {
+	return db.BeginTx(context.Background(), nil)
+}
+
+// Tx is a *sql.Tx whose errors are translated like those of its DB.
+type Tx struct {
+	*sql.Tx
+	translator support.PersistenceErrorTranslator
+}
+
+func (tx *Tx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	result, err := tx.Tx.ExecContext(ctx, query, args...)
+	return result, translate(tx.translator, err)
+}
+
+func (tx *Tx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return tx.ExecContext(context.Background(), query, args...)
+}
+
+func (tx *Tx) QueryContext(ctx context.Context, query string, args ...interface{}) (*Rows, error) {
+	rows, err := tx.Tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, translate(tx.translator, err)
+	}
+	return &Rows{Rows: rows, translator: tx.translator}, nil
+}
+
+func (tx *Tx) Query(query string, args ...interface{}) (*Rows, error) {
+	return tx.QueryContext(context.Background(), query, args...)
+}
+
+func (tx *Tx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *Row {
+	return &Row{row: tx.Tx.QueryRowContext(ctx, query, args...), translator: tx.translator}
+}
+
+func (tx *Tx) QueryRow(query string, args ...interface{}) *Row {
+	return tx.QueryRowContext(context.Background(), query, args...)
+}
+
+func (tx *Tx) PrepareContext(ctx context.Context, query string) (*Stmt, error) {
+	stmt, err := tx.Tx.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, translate(tx.translator, err)
+	}
+	return &Stmt{Stmt: stmt, translator: tx.translator}, nil
+}
+
+func (tx *Tx) Prepare(query string) (*Stmt, error) {
+	return tx.PrepareContext(context.Background(), query)
+}
+
+// StmtContext returns stmt bound to the transaction.
+func (tx *Tx) StmtContext(ctx context.Context, stmt *Stmt) *Stmt {
+	return &Stmt{Stmt: tx.Tx.StmtContext(ctx, stmt.Stmt), translator: tx.translator}
+}
+
+func (tx *Tx) Stmt(stmt *Stmt) *Stmt {
+	return tx.StmtContext(context.Background(), stmt)
+}
+
+func (tx *Tx) Commit() error {
+	return translate(tx.translator, tx.Tx.Commit())
+}
+
+func (tx *Tx) Rollback() error {
+	return translate(tx.translator, tx.Tx.Rollback())
+}
+
+// Rows is a *sql.Rows whose errors are translated.
+type Rows struct {
+	*sql.Rows
+	translator support.PersistenceErrorTranslator
+}
+
+func (rs *Rows) Scan(dest ...interface{}) error {
+	return translate(rs.translator, rs.Rows.Scan(dest...))
+}
+
+func (rs *Rows) Err() error {
+	return translate(rs.translator, rs.Rows.Err())
+}
+
+func (rs *Rows) Close() error {
+	return translate(rs.translator, rs.Rows.Close())
+}
+
+// Row is a *sql.Row whose errors are translated. sql.ErrNoRows is returned
+// unchanged.
+type Row struct {
+	row        *sql.Row
+	translator support.PersistenceErrorTranslator
+}
+
+func (r *Row) Scan(dest ...interface{}) error {
+	return translate(r.translator, r.row.Scan(dest...))
+}
+
+func (r *Row) Err() error {
+	return translate(r.translator, r.row.Err())
+}
+
+// Stmt is a *sql.Stmt whose errors are translated.
+type Stmt struct {
+	*sql.Stmt
+	translator support.PersistenceErrorTranslator
+}
+
+func (s *Stmt) ExecContext(ctx context.Context, args ...interface{}) (sql.Result, error) {
+	result, err := s.Stmt.ExecContext(ctx, args...)
+	return result, translate(s.translator, err)
+}
+
+func (s *Stmt) Exec(args ...interface{}) (sql.Result, error) {
+	return s.ExecContext(context.Background(), args...)
+}
+
+func (s *Stmt) QueryContext(ctx context.Context, args ...interface{}) (*Rows, error) {
+	rows, err := s.Stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, translate(s.translator, err)
+	}
+	return &Rows{Rows: rows, translator: s.translator}, nil
+}
+
+func (s *Stmt) Query(args ...interface{}) (*Rows, error) {
+	return s.QueryContext(context.Background(), args...)
+}
+
+func (s *Stmt) QueryRowContext(ctx context.Context, args ...interface{}) *Row {
+	return &Row{row: s.Stmt.QueryRowContext(ctx, args...), translator: s.translator}
+}
+
+func (s *Stmt) QueryRow(args ...interface{}) *Row {
+	return s.QueryRowContext(context.Background(), args...)
+}
+
+func (s *Stmt) Close() error {
+	return translate(s.translator, s.Stmt.Close())
+}
+
+// Conn is a *sql.Conn whose errors are translated.
+type Conn struct {
+	*sql.Conn
+	translator support.PersistenceErrorTranslator
+}
+
+func (c *Conn) PingContext(ctx context.Context) error {
+	return translate(c.translator, c.Conn.PingContext(ctx))
+}
+
+func (c *Conn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	result, err := c.Conn.ExecContext(ctx, query, args...)
+	return result, translate(c.translator, err)
+}
+
+func (c *Conn) QueryContext(ctx context.Context, query string, args ...interface{}) (*Rows, error) {
+	rows, err := c.Conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, translate(c.translator, err)
+	}
+	return &Rows{Rows: rows, translator: c.translator}, nil
+}
+
+func (c *Conn) QueryRowContext(ctx context.Context, query string, args ...interface{}) *Row {
+	return &Row{row: c.Conn.QueryRowContext(ctx, query, args...), translator: c.translator}
+}
+
+func (c *Conn) PrepareContext(ctx context.Context, query string) (*Stmt, error) {
+	stmt, err := c.Conn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, translate(c.translator, err)
+	}
+	return &Stmt{Stmt: stmt, translator: c.translator}, nil
+}
+
+func (c *Conn) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	tx, err := c.Conn.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, translate(c.translator, err)
+	}
+	return &Tx{Tx: tx, translator: c.translator}, nil
+}
+
+func (c *Conn) Close() error {
+	return translate(c.translator, c.Conn.Close())
+}
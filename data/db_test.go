@@ -0,0 +1,126 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/zbum/mantyboot/data/support"
+)
+
+type recordingTranslator struct {
+	translated []error
+}
+
+func (t *recordingTranslator) TranslateExceptionIfPossible(err error) support.DataAccessError {
+	t.translated = append(t.translated, err)
+	return support.ConnectionError{Message: err.Error()}
+}
+
+func TestDB_TranslatesErrors(t *testing.T) {
+	db, server := newFakeDB(t, "fakedb")
+	server.on("INSERT dup", fakeResult{err: stateError{"23505"}})
+	server.on("SELECT bad", fakeResult{err: stateError{"42601"}})
+	server.on("SELECT none", fakeResult{columns: []string{"id"}})
+	ctx := context.Background()
+
+	if _, err := db.ExecContext(ctx, "INSERT dup"); !errors.As(err, &support.DuplicateKeyError{}) {
+		t.Errorf("ExecContext() error = %v, want a DuplicateKeyError", err)
+	}
+	if _, err := db.QueryContext(ctx, "SELECT bad"); !errors.Is(err, support.ErrBadSqlGrammar) {
+		t.Errorf("QueryContext() error = %v, want bad SQL grammar", err)
+	}
+	var id int
+	if err := db.QueryRowContext(ctx, "SELECT bad").Scan(&id); !errors.Is(err, support.ErrBadSqlGrammar) {
+		t.Errorf("QueryRowContext().Scan() error = %v, want bad SQL grammar", err)
+	}
+	if err := db.QueryRowContext(ctx, "SELECT none").Scan(&id); err != sql.ErrNoRows {
+		t.Errorf("QueryRowContext().Scan() error = %v, want sql.ErrNoRows unchanged", err)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("BeginTx() error = %v", err)
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT dup"); !errors.Is(err, support.ErrDataIntegrityViolation) {
+		t.Errorf("Tx.ExecContext() error = %v, want a data integrity violation", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Errorf("Rollback() error = %v", err)
+	}
+	if err := tx.Rollback(); err != sql.ErrTxDone {
+		t.Errorf("second Rollback() error = %v, want sql.ErrTxDone unchanged", err)
+	}
+}
+
+func TestDB_KeepsDriverError(t *testing.T) {
+	db, server := newFakeDB(t, "fakedb")
+	server.on("INSERT dup", fakeResult{err: stateError{"23505"}})
+
+	_, err := db.ExecContext(context.Background(), "INSERT dup")
+	var driverErr stateError
+	if !errors.As(err, &driverErr) || driverErr.state != "23505" {
+		t.Errorf("ExecContext() error = %v, want the driver error in its chain", err)
+	}
+	if !errors.Is(err, support.ErrDataIntegrityViolation) {
+		t.Errorf("ExecContext() error = %v, want a data integrity violation", err)
+	}
+}
+
+func TestDB_PrepareAndConn(t *testing.T) {
+	db, server := newFakeDB(t, "fakedb")
+	server.on("INSERT dup", fakeResult{err: stateError{"23505"}})
+	m := NewTxManager(db)
+
+	err := m.RunInTx(context.Background(), nil, func(ctx context.Context) error {
+		stmt, err := db.PrepareContext(ctx, "INSERT dup")
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		_, err = stmt.ExecContext(ctx)
+		return err
+	})
+	if !errors.Is(err, support.ErrDataIntegrityViolation) {
+		t.Errorf("Stmt.ExecContext() error = %v, want a data integrity violation", err)
+	}
+	if got, want := server.statements(), "BEGIN; INSERT dup; ROLLBACK"; got != want {
+		t.Errorf("statements = %q, want the prepared statement in the transaction %q", got, want)
+	}
+
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("Conn() error = %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(context.Background(), "INSERT dup"); !errors.As(err, &support.DuplicateKeyError{}) {
+		t.Errorf("Conn.ExecContext() error = %v, want a DuplicateKeyError", err)
+	}
+}
+
+func TestDB_BeginTxError(t *testing.T) {
+	db, server := newFakeDB(t, "fakedb")
+	server.on("BEGIN", fakeResult{err: stateError{"08006"}})
+
+	if _, err := db.BeginTx(context.Background(), nil); !errors.Is(err, support.ErrTransientDataAccess) {
+		t.Errorf("BeginTx() error = %v, want a transient error", err)
+	}
+}
+
+func TestRegisterTranslator(t *testing.T) {
+	translator := &recordingTranslator{}
+	RegisterTranslator("fakedb-registered", translator)
+
+	db, server := newFakeDB(t, "fakedb-registered")
+	server.on("UPDATE", fakeResult{err: driver.ErrBadConn})
+	_, err := db.Exec("UPDATE")
+
+	if !errors.As(err, &support.ConnectionError{}) || len(translator.translated) == 0 {
+		t.Errorf("Exec() error = %v, want the registered translator to run", err)
+	}
+	if _, ok := Translator("unknown").(support.SqlStateErrorTranslator); !ok {
+		t.Error("Translator() of an unknown driver is not the SQLSTATE translator")
+	}
+}
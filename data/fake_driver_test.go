@@ -0,0 +1,175 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeServer answers statements with canned results and records what it ran.
+type fakeServer struct {
+	mu      sync.Mutex
+	results map[string]fakeResult
+	log     []string
+}
+
+type fakeResult struct {
+	columns []string
+	rows    [][]driver.Value
+	err     error
+}
+
+// stateError is a driver error with a SQLSTATE, translated by the fallback translator.
+type stateError struct {
+	state string
+}
+
+func (e stateError) Error() string {
+	return "sqlstate " + e.state
+}
+
+func (e stateError) SQLState() string {
+	return e.state
+}
+
+func newFakeDB(t *testing.T, driverName string) (*DB, *fakeServer) {
+	server := &fakeServer{results: make(map[string]fakeResult)}
+	db := sql.OpenDB(fakeConnector{server: server})
+	t.Cleanup(func() { db.Close() })
+	return NewDB(db, driverName), server
+}
+
+func (s *fakeServer) on(query string, result fakeResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results[query] = result
+}
+
+func (s *fakeServer) run(statement string) fakeResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.log = append(s.log, statement)
+	return s.results[statement]
+}
+
+func (s *fakeServer) statements() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return strings.Join(s.log, "; ")
+}
+
+type fakeConnector struct {
+	server *fakeServer
+}
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{server: c.server}, nil
+}
+
+func (c fakeConnector) Driver() driver.Driver {
+	return fakeDriver{}
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, fmt.Errorf("use fakeConnector")
+}
+
+type fakeConn struct {
+	server *fakeServer
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{server: c.server, query: query}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *fakeConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	statement := "BEGIN"
+	if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+		statement += " " + sql.IsolationLevel(opts.Isolation).String()
+	}
+	if opts.ReadOnly {
+		statement += " READ ONLY"
+	}
+	if err := c.server.run(statement).err; err != nil {
+		return nil, err
+	}
+	return fakeTx{server: c.server}, nil
+}
+
+type fakeTx struct {
+	server *fakeServer
+}
+
+func (tx fakeTx) Commit() error {
+	return tx.server.run("COMMIT").err
+}
+
+func (tx fakeTx) Rollback() error {
+	return tx.server.run("ROLLBACK").err
+}
+
+type fakeStmt struct {
+	server *fakeServer
+	query  string
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	result := s.server.run(s.query)
+	if result.err != nil {
+		return nil, result.err
+	}
+	return driver.RowsAffected(len(result.rows)), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	result := s.server.run(s.query)
+	if result.err != nil {
+		return nil, result.err
+	}
+	return &fakeRows{columns: result.columns, rows: result.rows}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
@@ -1,55 +1,26 @@
 package mysql
 
 import (
+	"context"
+	"database/sql/driver"
 	"errors"
 	"fmt"
-	"strings"
+	"net"
+
 	"github.com/go-sql-driver/mysql"
+	"github.com/zbum/mantyboot/data"
 	"github.com/zbum/mantyboot/data/support"
 	merrors "github.com/zbum/mantyboot/errors"
 )
 
-type DuplicateKeyError struct {
-	Table   string
-	Column  string
-	Message string
-}
-
-func (d DuplicateKeyError) Error() string {
-	if d.Table != "" && d.Column != "" {
-		return fmt.Sprintf("duplicate key error on table '%s' column '%s': %s", d.Table, d.Column, d.Message)
-	}
-	return fmt.Sprintf("duplicate key error: %s", d.Message)
-}
-
-type FkConstraintError struct {
-	Table      string
-	Constraint string
-	Message    string
-}
-
-func (d FkConstraintError) Error() string {
-	if d.Table != "" && d.Constraint != "" {
-		return fmt.Sprintf("foreign key constraint error on table '%s' constraint '%s': %s", d.Table, d.Constraint, d.Message)
-	}
-	return fmt.Sprintf("foreign key constraint error: %s", d.Message)
-}
-
-type ConnectionError struct {
-	Message string
-}
-
-func (d ConnectionError) Error() string {
-	return fmt.Sprintf("database connection error: %s", d.Message)
-}
-
-type SyntaxError struct {
-	Message string
-}
-
-func (d SyntaxError) Error() string {
-	return fmt.Sprintf("SQL syntax error: %s", d.Message)
-}
+// The translated errors are the driver independent types of data/support.
+type (
+	DuplicateKeyError   = support.DuplicateKeyError
+	FkConstraintError   = support.FkConstraintError
+	ConnectionError     = support.ConnectionError
+	ConnectionLostError = support.ConnectionLostError
+	SyntaxError         = support.BadSqlGrammarError
+)
 
 type MysqlErrorTranslator struct {
 }
@@ -59,79 +30,122 @@ func (t MysqlErrorTranslator) TranslateExceptionIfPossible(err error) support.Da
 		return nil
 	}
 
+	// driver.ErrBadConn and failed dials guarantee that nothing reached the
+	// server. Any other broken connection may have lost the reply to a
+	// statement that was applied.
+	var netErr net.Error
+	var opErr *net.OpError
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		// Context errors implement net.Error, but callers check for them as they are.
+		return err
+	case errors.Is(err, driver.ErrBadConn), errors.As(err, &opErr) && opErr.Op == "dial":
+		return ConnectionError{
+			Message: err.Error(),
+			Timeout: errors.As(err, &netErr) && netErr.Timeout(),
+		}
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return support.QueryTimeoutError{
+			Message: err.Error(),
+		}
+	case errors.Is(err, mysql.ErrInvalidConn), errors.As(err, &netErr):
+		return ConnectionLostError{
+			Message: err.Error(),
+		}
+	}
+
 	var mysqlErr *mysql.MySQLError
 	if !errors.As(err, &mysqlErr) {
 		return merrors.WrapDatabaseError(err, "unknown", "failed to translate MySQL error")
 	}
 
 	switch mysqlErr.Number {
-	case 1062: // Duplicate entry
-		return DuplicateKeyError{
-			Table:   extractTableFromMessage(mysqlErr.Message),
-			Column:  extractColumnFromMessage(mysqlErr.Message),
+	case 1062, 1586: // Duplicate entry
+		duplicate := parseDuplicateEntry(mysqlErr.Message)
+		duplicate.Message = mysqlErr.Message
+		return duplicate
+	case 1452, 1451, 1216, 1217: // Cannot add/update a child row or delete/update a parent row
+		constraint := parseForeignKey(mysqlErr.Message)
+		constraint.Message = mysqlErr.Message
+		return constraint
+	case 1048, 1364: // Column cannot be null, Field doesn't have a default value
+		return support.NotNullError{
+			Column:  parseColumn(mysqlErr.Message),
+			Message: mysqlErr.Message,
+		}
+	case 1406: // Data too long for column
+		return support.DataTooLongError{
+			Column:  parseColumn(mysqlErr.Message),
+			Message: mysqlErr.Message,
+		}
+	case 1264: // Out of range value for column
+		return support.DataError{
+			SQLState: "22003",
+			Message:  mysqlErr.Message,
+		}
+	case 3819, 4025: // Check constraint violated (MySQL 8.0.16+, MariaDB)
+		return support.DataIntegrityViolationError{
+			SQLState: "23000",
+			Message:  mysqlErr.Message,
+		}
+	case 1213: // Deadlock found when trying to get lock
+		return support.DeadlockError{
+			Message: mysqlErr.Message,
+		}
+	case 1205: // Lock wait timeout exceeded
+		return support.LockTimeoutError{
 			Message: mysqlErr.Message,
 		}
-	case 1452: // Cannot add or update a child row: a foreign key constraint fails
-		return FkConstraintError{
-			Table:      extractTableFromMessage(mysqlErr.Message),
-			Constraint: extractConstraintFromMessage(mysqlErr.Message),
-			Message:    mysqlErr.Message,
+	case 3024, 1317, 1969: // max_execution_time exceeded, Query interrupted, MariaDB max_statement_time exceeded
+		return support.QueryTimeoutError{
+			Message: mysqlErr.Message,
+		}
+	case 1290, 1792: // --read-only server, read-only transaction
+		return support.ReadOnlyError{
+			Message: mysqlErr.Message,
 		}
-	case 2002, 2003, 2006, 2013: // Connection errors
+	case 2002, 2003, 1040: // Can't connect, Too many connections
 		return ConnectionError{
 			Message: mysqlErr.Message,
 		}
-	case 1064, 1146, 1054: // Syntax errors
-		return SyntaxError{
+	case 2006, 2013, 1053, 1927, 4031: // Server has gone away, Lost connection during query, shutdown, connection killed, disconnected for inactivity
+		return ConnectionLostError{
 			Message: mysqlErr.Message,
 		}
+	case 1064, 1146, 1054: // Syntax error, Unknown table, Unknown column
+		return SyntaxError{
+			SQLState: sqlState(mysqlErr),
+			Message:  mysqlErr.Message,
+		}
 	default:
-		return merrors.WrapDatabaseError(err, "unknown", fmt.Sprintf("unhandled MySQL error %d", mysqlErr.Number))
-	}
-}
+		state := sqlState(mysqlErr)
+		if translated, ok := support.TranslateSqlState(state, mysqlErr.Message); ok {
+			return translated
+		}
 
-func extractTableFromMessage(message string) string {
-	// Simple extraction - can be enhanced with regex
-	if len(message) > 0 {
-		// Look for table name in quotes
-		start := strings.Index(message, "`")
-		if start != -1 {
-			end := strings.Index(message[start+1:], "`")
-			if end != -1 {
-				return message[start+1 : start+1+end]
-			}
+		attributed := merrors.With(err, "mysql_errno", mysqlErr.Number)
+		if state != "" {
+			attributed = merrors.With(attributed, "sql_state", state)
 		}
+		return merrors.WrapDatabaseError(attributed, "unknown", fmt.Sprintf("unhandled MySQL error %d", mysqlErr.Number))
 	}
-	return ""
 }
 
-func extractColumnFromMessage(message string) string {
-	// Simple extraction - can be enhanced with regex
-	if len(message) > 0 {
-		// Look for column name in quotes
-		start := strings.LastIndex(message, "`")
-		if start != -1 {
-			end := strings.Index(message[start+1:], "`")
-			if end != -1 {
-				return message[start+1 : start+1+end]
-			}
-		}
-	}
-	return ""
+// defaultSQLStates are the SQLSTATEs of errors that are reported without one,
+// as in tests or by older servers.
+var defaultSQLStates = map[uint16]string{
+	1064: "42000",
+	1146: "42S02",
+	1054: "42S22",
 }
 
-func extractConstraintFromMessage(message string) string {
-	// Simple extraction - can be enhanced with regex
-	if len(message) > 0 {
-		// Look for constraint name
-		start := strings.Index(message, "constraint")
-		if start != -1 {
-			// Extract constraint name after "constraint"
-			parts := strings.Split(message[start:], " ")
-			if len(parts) > 1 {
-				return strings.Trim(parts[1], "`'")
-			}
-		}
+func sqlState(mysqlErr *mysql.MySQLError) string {
+	if mysqlErr.SQLState != [5]byte{} {
+		return string(mysqlErr.SQLState[:])
 	}
-	return ""
+	return defaultSQLStates[mysqlErr.Number]
+}
+
+func init() {
+	data.RegisterTranslator("mysql", MysqlErrorTranslator{})
 }
@@ -1,10 +1,18 @@
 package mysql
 
 import (
-	"github.com/go-sql-driver/mysql"
-	"github.com/zbum/mantyboot/data/support"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
 	"reflect"
 	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/zbum/mantyboot/data"
+	"github.com/zbum/mantyboot/data/support"
+	merrors "github.com/zbum/mantyboot/errors"
 )
 
 func TestMysqlErrorTranslator_TranslateExceptionIfPossible(t1 *testing.T) {
@@ -38,8 +46,6 @@ func TestMysqlErrorTranslator_TranslateExceptionIfPossible(t1 *testing.T) {
 				},
 			},
 			want: FkConstraintError{
-				Table:      "",
-				Constraint: "error",
 				Message: "fk constraint error",
 			},
 		},
@@ -53,3 +59,226 @@ func TestMysqlErrorTranslator_TranslateExceptionIfPossible(t1 *testing.T) {
 		})
 	}
 }
+
+func TestMysqlErrorTranslator_Classification(t1 *testing.T) {
+	tests := []struct {
+		name      string
+		number    uint16
+		predicate func(error) bool
+	}{
+		{name: "duplicate is conflict", number: 1062, predicate: merrors.IsConflict},
+		{name: "fk constraint is conflict", number: 1452, predicate: merrors.IsConflict},
+		{name: "connection is transient", number: 2006, predicate: merrors.IsTransient},
+		{name: "refused connection is retryable", number: 2003, predicate: merrors.IsRetryable},
+		{name: "lost connection is not retryable", number: 2013, predicate: func(err error) bool { return merrors.IsTransient(err) && !merrors.IsRetryable(err) }},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			t := MysqlErrorTranslator{}
+			err := fmt.Errorf("repository: %w", t.TranslateExceptionIfPossible(&mysql.MySQLError{Number: tt.number, Message: "error"}))
+			if !tt.predicate(err) {
+				t1.Errorf("predicate failed for MySQL error %d", tt.number)
+			}
+		})
+	}
+}
+
+func TestMysqlErrorTranslator_Categories(t1 *testing.T) {
+	t := MysqlErrorTranslator{}
+	tests := []struct {
+		number   uint16
+		category error
+	}{
+		{number: 1062, category: support.ErrDataIntegrityViolation},
+		{number: 1452, category: support.ErrDataIntegrityViolation},
+		{number: 2013, category: support.ErrTransientDataAccess},
+		{number: 1064, category: support.ErrBadSqlGrammar},
+	}
+	for _, tt := range tests {
+		err := t.TranslateExceptionIfPossible(&mysql.MySQLError{Number: tt.number, Message: "error"})
+		if !errors.Is(err, tt.category) {
+			t1.Errorf("MySQL error %d is not %v", tt.number, tt.category)
+		}
+	}
+}
+
+func TestMysqlErrorTranslator_ErrorCodes(t1 *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want support.DataAccessError
+	}{
+		{
+			name: "duplicate entry",
+			err:  &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'kim@example.com' for key 'users.email_uk'"},
+			want: DuplicateKeyError{Table: "users", Key: "email_uk", Value: "kim@example.com", Message: "Duplicate entry 'kim@example.com' for key 'users.email_uk'"},
+		},
+		{
+			name: "parent row delete",
+			err:  &mysql.MySQLError{Number: 1451, Message: "Cannot delete or update a parent row"},
+			want: FkConstraintError{Message: "Cannot delete or update a parent row"},
+		},
+		{
+			name: "column cannot be null",
+			err:  &mysql.MySQLError{Number: 1048, Message: "Column 'name' cannot be null"},
+			want: support.NotNullError{Column: "name", Message: "Column 'name' cannot be null"},
+		},
+		{
+			name: "field without default",
+			err:  &mysql.MySQLError{Number: 1364, Message: "Field 'name' doesn't have a default value"},
+			want: support.NotNullError{Column: "name", Message: "Field 'name' doesn't have a default value"},
+		},
+		{
+			name: "data too long",
+			err:  &mysql.MySQLError{Number: 1406, Message: "Data too long for column 'email' at row 1"},
+			want: support.DataTooLongError{Column: "email", Message: "Data too long for column 'email' at row 1"},
+		},
+		{
+			name: "out of range",
+			err:  &mysql.MySQLError{Number: 1264, Message: "Out of range value for column 'age' at row 1"},
+			want: support.DataError{SQLState: "22003", Message: "Out of range value for column 'age' at row 1"},
+		},
+		{
+			name: "MariaDB check constraint",
+			err:  &mysql.MySQLError{Number: 4025, Message: "CONSTRAINT `age_positive` failed for `shop`.`users`"},
+			want: support.DataIntegrityViolationError{SQLState: "23000", Message: "CONSTRAINT `age_positive` failed for `shop`.`users`"},
+		},
+		{
+			name: "deadlock",
+			err:  &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock; try restarting transaction"},
+			want: support.DeadlockError{Message: "Deadlock found when trying to get lock; try restarting transaction"},
+		},
+		{
+			name: "lock wait timeout",
+			err:  &mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded; try restarting transaction"},
+			want: support.LockTimeoutError{Message: "Lock wait timeout exceeded; try restarting transaction"},
+		},
+		{
+			name: "max execution time",
+			err:  &mysql.MySQLError{Number: 3024, Message: "Query execution was interrupted, maximum statement execution time exceeded"},
+			want: support.QueryTimeoutError{Message: "Query execution was interrupted, maximum statement execution time exceeded"},
+		},
+		{
+			name: "query interrupted",
+			err:  &mysql.MySQLError{Number: 1317, Message: "Query execution was interrupted"},
+			want: support.QueryTimeoutError{Message: "Query execution was interrupted"},
+		},
+		{
+			name: "MariaDB max statement time",
+			err:  &mysql.MySQLError{Number: 1969, Message: "Query execution was interrupted (max_statement_time exceeded)"},
+			want: support.QueryTimeoutError{Message: "Query execution was interrupted (max_statement_time exceeded)"},
+		},
+		{
+			name: "read-only server",
+			err:  &mysql.MySQLError{Number: 1290, Message: "The MySQL server is running with the --read-only option so it cannot execute this statement"},
+			want: support.ReadOnlyError{Message: "The MySQL server is running with the --read-only option so it cannot execute this statement"},
+		},
+		{
+			name: "read-only transaction",
+			err:  &mysql.MySQLError{Number: 1792, Message: "Cannot execute statement in a READ ONLY transaction."},
+			want: support.ReadOnlyError{Message: "Cannot execute statement in a READ ONLY transaction."},
+		},
+		{
+			name: "too many connections",
+			err:  &mysql.MySQLError{Number: 1040, Message: "Too many connections"},
+			want: ConnectionError{Message: "Too many connections"},
+		},
+		{
+			name: "MariaDB connection killed",
+			err:  &mysql.MySQLError{Number: 1927, Message: "Connection was killed"},
+			want: ConnectionLostError{Message: "Connection was killed"},
+		},
+		{
+			name: "unknown table",
+			err:  &mysql.MySQLError{Number: 1146, Message: "Table 'shop.userz' doesn't exist"},
+			want: SyntaxError{SQLState: "42S02", Message: "Table 'shop.userz' doesn't exist"},
+		},
+		{
+			name: "unlisted integrity violation falls back to the SQLSTATE class",
+			err:  &mysql.MySQLError{Number: 1557, SQLState: [5]byte{'2', '3', '0', '0', '0'}, Message: "Upholding foreign key constraints for table 't1', entry '1', key 1 would lead to a duplicate entry"},
+			want: support.DataIntegrityViolationError{SQLState: "23000", Message: "Upholding foreign key constraints for table 't1', entry '1', key 1 would lead to a duplicate entry"},
+		},
+		{
+			name: "unlisted connection failure is not retryable",
+			err:  &mysql.MySQLError{Number: 1158, SQLState: [5]byte{'0', '8', 'S', '0', '1'}, Message: "Got an error reading communication packets"},
+			want: ConnectionLostError{Message: "Got an error reading communication packets"},
+		},
+		{
+			name: "bad connection",
+			err:  fmt.Errorf("query: %w", driver.ErrBadConn),
+			want: ConnectionError{Message: "query: driver: bad connection"},
+		},
+		{
+			name: "network error",
+			err:  &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+			want: ConnectionError{Message: "dial tcp: connection refused"},
+		},
+		{
+			name: "network error after dial",
+			err:  &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")},
+			want: ConnectionLostError{Message: "read tcp: connection reset by peer"},
+		},
+		{
+			name: "dial timeout",
+			err:  &net.OpError{Op: "dial", Net: "tcp", Err: timeoutError{}},
+			want: ConnectionError{Message: "dial tcp: i/o timeout", Timeout: true},
+		},
+		{
+			name: "read timeout",
+			err:  &net.OpError{Op: "read", Net: "tcp", Err: timeoutError{}},
+			want: support.QueryTimeoutError{Message: "read tcp: i/o timeout"},
+		},
+		{
+			name: "deadline exceeded",
+			err:  fmt.Errorf("query: %w", context.DeadlineExceeded),
+			want: fmt.Errorf("query: %w", context.DeadlineExceeded),
+		},
+		{
+			name: "canceled",
+			err:  context.Canceled,
+			want: context.Canceled,
+		},
+		{
+			name: "invalid connection",
+			err:  mysql.ErrInvalidConn,
+			want: ConnectionLostError{Message: "invalid connection"},
+		},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			t := MysqlErrorTranslator{}
+			if got := t.TranslateExceptionIfPossible(tt.err); !reflect.DeepEqual(got, tt.want) {
+				t1.Errorf("TranslateExceptionIfPossible() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+// timeoutError is a net.Error that timed out, like the errors of deadlines
+// set on a net.Conn.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestMysqlErrorTranslator_Timeouts(t *testing.T) {
+	translator := MysqlErrorTranslator{}
+	if got := translator.TranslateExceptionIfPossible(context.DeadlineExceeded); !merrors.IsTimeout(got) {
+		t.Errorf("IsTimeout(%#v) = false, want true", got)
+	}
+	read := translator.TranslateExceptionIfPossible(&net.OpError{Op: "read", Net: "tcp", Err: timeoutError{}})
+	if !merrors.IsTimeout(read) || merrors.IsRetryable(read) {
+		t.Errorf("read timeout %#v: IsTimeout = %v, IsRetryable = %v, want true, false", read, merrors.IsTimeout(read), merrors.IsRetryable(read))
+	}
+	dial := translator.TranslateExceptionIfPossible(&net.OpError{Op: "dial", Net: "tcp", Err: timeoutError{}})
+	if !merrors.IsTimeout(dial) || !merrors.IsRetryable(dial) {
+		t.Errorf("dial timeout %#v: IsTimeout = %v, IsRetryable = %v, want true, true", dial, merrors.IsTimeout(dial), merrors.IsRetryable(dial))
+	}
+}
+
+func TestMysqlErrorTranslator_Registered(t1 *testing.T) {
+	if _, ok := data.Translator("mysql").(MysqlErrorTranslator); !ok {
+		t1.Errorf("Translator(\"mysql\") = %T, want MysqlErrorTranslator", data.Translator("mysql"))
+	}
+}
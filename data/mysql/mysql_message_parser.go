@@ -0,0 +1,69 @@
+package mysql
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// Duplicate entry 'kim@example.com' for key 'email_uk'        (MySQL 5.7, MariaDB)
+	// Duplicate entry 'kim@example.com' for key 'users.email_uk'  (MySQL 8.0.19+)
+	duplicateEntryPattern = regexp.MustCompile(`Duplicate entry '(.*)' for key '([^']+)'`)
+
+	// Cannot add or update a child row: a foreign key constraint fails
+	// (`shop`.`orders`, CONSTRAINT `orders_user_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`))
+	foreignKeyPattern = regexp.MustCompile("\\((?:`[^`]+`\\.)?`([^`]+)`, CONSTRAINT `([^`]+)` FOREIGN KEY \\(([^)]+)\\) REFERENCES (?:`[^`]+`\\.)?`([^`]+)` \\(([^)]+)\\)")
+
+	// Column 'name' cannot be null, Field 'name' doesn't have a default value,
+	// Data too long for column 'name' at row 1
+	columnPattern = regexp.MustCompile(`(?:[Cc]olumn|Field) '([^']+)'`)
+)
+
+// parseDuplicateEntry reads the value, key and, for MySQL 8.0, the table of a
+// duplicate entry message.
+func parseDuplicateEntry(message string) DuplicateKeyError {
+	matches := duplicateEntryPattern.FindStringSubmatch(message)
+	if matches == nil {
+		return DuplicateKeyError{}
+	}
+
+	duplicate := DuplicateKeyError{Value: matches[1], Key: matches[2]}
+	if table, key, ok := strings.Cut(matches[2], "."); ok {
+		duplicate.Table, duplicate.Key = table, key
+	}
+	return duplicate
+}
+
+// parseForeignKey reads the child table, constraint, columns and parent table
+// of a child or parent row foreign key message. Composite keys keep their
+// columns as a comma separated list.
+func parseForeignKey(message string) FkConstraintError {
+	matches := foreignKeyPattern.FindStringSubmatch(message)
+	if matches == nil {
+		return FkConstraintError{}
+	}
+	return FkConstraintError{
+		Table:            matches[1],
+		Constraint:       matches[2],
+		Column:           unquoteColumns(matches[3]),
+		ReferencedTable:  matches[4],
+		ReferencedColumn: unquoteColumns(matches[5]),
+	}
+}
+
+func parseColumn(message string) string {
+	matches := columnPattern.FindStringSubmatch(message)
+	if matches == nil {
+		return ""
+	}
+	return matches[1]
+}
+
+// unquoteColumns turns "`a`, `b`" into "a, b".
+func unquoteColumns(columns string) string {
+	names := strings.Split(columns, ",")
+	for i, name := range names {
+		names[i] = strings.Trim(strings.TrimSpace(name), "`")
+	}
+	return strings.Join(names, ", ")
+}
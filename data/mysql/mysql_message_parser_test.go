@@ -0,0 +1,132 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseDuplicateEntry(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    DuplicateKeyError
+	}{
+		{
+			name:    "MySQL 5.7",
+			message: "Duplicate entry 'kim@example.com' for key 'email_uk'",
+			want:    DuplicateKeyError{Key: "email_uk", Value: "kim@example.com"},
+		},
+		{
+			name:    "MySQL 8.0",
+			message: "Duplicate entry 'kim@example.com' for key 'users.email_uk'",
+			want:    DuplicateKeyError{Table: "users", Key: "email_uk", Value: "kim@example.com"},
+		},
+		{
+			name:    "MySQL 8.0 composite primary key",
+			message: "Duplicate entry '1-42' for key 'members.PRIMARY'",
+			want:    DuplicateKeyError{Table: "members", Key: "PRIMARY", Value: "1-42"},
+		},
+		{
+			name:    "MariaDB",
+			message: "Duplicate entry 'o'brien' for key 'name_uk'",
+			want:    DuplicateKeyError{Key: "name_uk", Value: "o'brien"},
+		},
+		{
+			name:    "unknown format",
+			message: "duplicate error",
+			want:    DuplicateKeyError{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseDuplicateEntry(tt.message); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDuplicateEntry() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseForeignKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    FkConstraintError
+	}{
+		{
+			name:    "MySQL 5.7 child row",
+			message: "Cannot add or update a child row: a foreign key constraint fails (`shop`.`orders`, CONSTRAINT `orders_user_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`))",
+			want: FkConstraintError{
+				Table:            "orders",
+				Constraint:       "orders_user_fk",
+				Column:           "user_id",
+				ReferencedTable:  "users",
+				ReferencedColumn: "id",
+			},
+		},
+		{
+			name:    "MySQL 8.0 parent row",
+			message: "Cannot delete or update a parent row: a foreign key constraint fails (`shop`.`orders`, CONSTRAINT `orders_user_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`))",
+			want: FkConstraintError{
+				Table:            "orders",
+				Constraint:       "orders_user_fk",
+				Column:           "user_id",
+				ReferencedTable:  "users",
+				ReferencedColumn: "id",
+			},
+		},
+		{
+			name:    "MariaDB with referential action and composite key",
+			message: "Cannot add or update a child row: a foreign key constraint fails (`shop`.`order_items`, CONSTRAINT `items_order_fk` FOREIGN KEY (`order_id`, `shop_id`) REFERENCES `orders` (`id`, `shop_id`) ON DELETE CASCADE)",
+			want: FkConstraintError{
+				Table:            "order_items",
+				Constraint:       "items_order_fk",
+				Column:           "order_id, shop_id",
+				ReferencedTable:  "orders",
+				ReferencedColumn: "id, shop_id",
+			},
+		},
+		{
+			name:    "parent in another schema",
+			message: "Cannot add or update a child row: a foreign key constraint fails (`shop`.`orders`, CONSTRAINT `orders_user_fk` FOREIGN KEY (`user_id`) REFERENCES `auth`.`users` (`id`))",
+			want: FkConstraintError{
+				Table:            "orders",
+				Constraint:       "orders_user_fk",
+				Column:           "user_id",
+				ReferencedTable:  "users",
+				ReferencedColumn: "id",
+			},
+		},
+		{
+			name:    "unknown format",
+			message: "fk constraint error",
+			want:    FkConstraintError{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseForeignKey(tt.message); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseForeignKey() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseColumn(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{message: "Column 'name' cannot be null", want: "name"},
+		{message: "Field 'name' doesn't have a default value", want: "name"},
+		{message: "Data too long for column 'email' at row 1", want: "email"},
+		{message: "Out of range value for column 'age' at row 1", want: "age"},
+		{message: "error", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			if got := parseColumn(tt.message); got != tt.want {
+				t.Errorf("parseColumn() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
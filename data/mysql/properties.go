@@ -0,0 +1,186 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/zbum/mantyboot/configuration"
+	"github.com/zbum/mantyboot/data"
+	merrors "github.com/zbum/mantyboot/errors"
+)
+
+const DefaultPort = 3306
+
+// Properties configure a MySQL data source. They bind from the database key
+// of the application configuration:
+//
+//	database:
+//	  host: db.example.com
+//	  user: app
+//	  database: shop
+//	  timezone: Asia/Seoul
+//	  pool:
+//	    max-open: 20
+//	    max-lifetime: 30m
+type Properties struct {
+	Host     string            `yaml:"host" validate:"required"`
+	Port     int               `yaml:"port" validate:"min=0,max=65535"`
+	User     string            `yaml:"user" validate:"required"`
+	Password string            `yaml:"password"`
+	Database string            `yaml:"database"`
+	Params   map[string]string `yaml:"params"`
+	// TLS is "true", "false", "skip-verify", "preferred" or the name of a
+	// config registered with mysql.RegisterTLSConfig.
+	TLS string `yaml:"tls"`
+	// Timezone is the location of DATETIME values, such as "Asia/Seoul".
+	// Setting it also enables parseTime.
+	Timezone string `yaml:"timezone"`
+
+	Pool    PoolProperties    `yaml:"pool"`
+	Startup StartupProperties `yaml:"startup"`
+}
+
+// PoolProperties configure the connection pool. Zero values keep the
+// database/sql defaults.
+type PoolProperties struct {
+	MaxOpen     int           `yaml:"max-open" validate:"min=0"`
+	MaxIdle     int           `yaml:"max-idle" validate:"min=0"`
+	MaxLifetime time.Duration `yaml:"max-lifetime"`
+	MaxIdleTime time.Duration `yaml:"max-idle-time"`
+}
+
+// StartupProperties make OpenDB ping the database before returning it.
+type StartupProperties struct {
+	Ping        bool          `yaml:"ping"`
+	MaxAttempts int           `yaml:"max-attempts" validate:"min=0"`
+	Interval    time.Duration `yaml:"interval"`
+}
+
+// Validate checks the properties together with their pool and startup
+// properties, and reports the violations of all of them in one error.
+func (p *Properties) Validate() error {
+	var violations []merrors.ValidationError
+	var failures []error
+	for _, properties := range []interface{}{p, &p.Pool, &p.Startup} {
+		err := configuration.ValidateStruct(properties)
+		var validationErr *merrors.ValidationError
+		switch {
+		case err == nil:
+		case errors.As(err, &validationErr):
+			for _, violation := range validationErr.Violations() {
+				violations = append(violations, violation)
+				failures = append(failures, &violation)
+			}
+		default:
+			return err
+		}
+	}
+
+	if len(violations) > 0 {
+		return merrors.NewAggregateValidationError("database", fmt.Sprintf("validation failed: %v", failures), violations)
+	}
+	return nil
+}
+
+// Config returns the driver configuration described by the properties.
+func (p *Properties) Config() (*mysql.Config, error) {
+	port := p.Port
+	if port == 0 {
+		port = DefaultPort
+	}
+
+	cfg := mysql.NewConfig()
+	cfg.User = p.User
+	cfg.Passwd = p.Password
+	cfg.Net = "tcp"
+	cfg.Addr = net.JoinHostPort(p.Host, strconv.Itoa(port))
+	cfg.DBName = p.Database
+	cfg.TLSConfig = p.TLS
+	if len(p.Params) > 0 {
+		cfg.Params = make(map[string]string, len(p.Params))
+		for name, value := range p.Params {
+			cfg.Params[name] = value
+		}
+	}
+	if p.Timezone != "" {
+		location, err := time.LoadLocation(p.Timezone)
+		if err != nil {
+			return nil, merrors.WrapConfigurationError(err, "invalid database timezone "+p.Timezone)
+		}
+		cfg.Loc = location
+		cfg.ParseTime = true
+	}
+	return cfg, nil
+}
+
+func (p *Properties) FormatDSN() (string, error) {
+	cfg, err := p.Config()
+	if err != nil {
+		return "", err
+	}
+	return cfg.FormatDSN(), nil
+}
+
+// OpenDB validates the properties, opens the database and configures its
+// pool. When Startup.Ping is set, it pings the database and retries failed
+// connections up to Startup.MaxAttempts times.
+func (p *Properties) OpenDB(ctx context.Context) (*sql.DB, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	cfg, err := p.Config()
+	if err != nil {
+		return nil, err
+	}
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		return nil, merrors.WrapConfigurationError(err, "invalid database configuration")
+	}
+
+	db := sql.OpenDB(connector)
+	db.SetMaxOpenConns(p.Pool.MaxOpen)
+	if p.Pool.MaxIdle > 0 {
+		db.SetMaxIdleConns(p.Pool.MaxIdle)
+	}
+	db.SetConnMaxLifetime(p.Pool.MaxLifetime)
+	db.SetConnMaxIdleTime(p.Pool.MaxIdleTime)
+
+	if p.Startup.Ping {
+		if err := p.ping(ctx, data.NewDB(db, "mysql")); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// Open is OpenDB wrapped in a data.DB that translates MySQL errors.
+func (p *Properties) Open(ctx context.Context) (*data.DB, error) {
+	db, err := p.OpenDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return data.NewDB(db, "mysql"), nil
+}
+
+func (p *Properties) ping(ctx context.Context, db *data.DB) error {
+	interval := p.Startup.Interval
+	if interval == 0 {
+		interval = time.Second
+	}
+	policy := &data.RetryPolicy{
+		MaxAttempts:     p.Startup.MaxAttempts,
+		InitialInterval: interval,
+		Multiplier:      1.5,
+		MaxInterval:     10 * interval,
+		// A ping changes nothing, so a lost connection is worth another attempt too.
+		Retryable: merrors.IsTransient,
+	}
+	return policy.Do(ctx, db.PingContext)
+}
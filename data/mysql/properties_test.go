@@ -0,0 +1,166 @@
+package mysql
+
+import (
+	"context"
+	stderrors "errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zbum/mantyboot/configuration/configtest"
+	"github.com/zbum/mantyboot/data/support"
+	merrors "github.com/zbum/mantyboot/errors"
+)
+
+type applicationConfig struct {
+	Database Properties `yaml:"database"`
+}
+
+func TestProperties_Bind(t *testing.T) {
+	config := configtest.New[applicationConfig](t, configtest.Fixture{YAML: []string{`
+database:
+  host: db.example.com
+  port: 3307
+  user: app
+  password: secret
+  database: shop
+  params:
+    charset: utf8mb4
+  tls: skip-verify
+  timezone: Asia/Seoul
+  pool:
+    max-open: 20
+    max-idle: 5
+    max-lifetime: 30m
+    max-idle-time: 5m
+  startup:
+    ping: true
+    max-attempts: 5
+    interval: 2s
+`}})
+
+	p := config.GetConfiguration().Database
+	if p.Pool.MaxLifetime != 30*time.Minute || p.Startup.Interval != 2*time.Second || !p.Startup.Ping {
+		t.Errorf("Properties = %+v", p)
+	}
+
+	dsn, err := p.FormatDSN()
+	if err != nil {
+		t.Fatalf("FormatDSN() error = %v", err)
+	}
+	want := "app:secret@tcp(db.example.com:3307)/shop?loc=Asia%2FSeoul&parseTime=true&tls=skip-verify&charset=utf8mb4"
+	if dsn != want {
+		t.Errorf("FormatDSN() = %q, want %q", dsn, want)
+	}
+}
+
+func TestProperties_FormatDSN(t *testing.T) {
+	tests := []struct {
+		name       string
+		properties Properties
+		want       string
+	}{
+		{name: "default port", properties: Properties{Host: "localhost", User: "root"}, want: "root@tcp(localhost:3306)/"},
+		{name: "IPv6 host", properties: Properties{Host: "::1", Port: 3307, User: "root", Database: "shop"}, want: "root@tcp([::1]:3307)/shop"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := tt.properties.FormatDSN(); err != nil || got != tt.want {
+				t.Errorf("FormatDSN() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	p := Properties{Host: "localhost", User: "root", Timezone: "Mars/Olympus"}
+	var configErr merrors.ConfigurationError
+	if _, err := p.FormatDSN(); !stderrors.As(err, &configErr) {
+		t.Errorf("FormatDSN() error = %v, want a ConfigurationError", err)
+	}
+}
+
+func TestProperties_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		properties Properties
+		wantCode   string
+	}{
+		{name: "valid", properties: Properties{Host: "localhost", User: "root"}},
+		{name: "missing host", properties: Properties{User: "root"}, wantCode: merrors.CodeRequired},
+		{name: "port out of range", properties: Properties{Host: "localhost", User: "root", Port: 70000}, wantCode: merrors.CodeMax},
+		{name: "negative pool size", properties: Properties{Host: "localhost", User: "root", Pool: PoolProperties{MaxOpen: -1}}, wantCode: merrors.CodeMin},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.properties.Validate()
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			var validationErr *merrors.ValidationError
+			if !stderrors.As(err, &validationErr) || len(validationErr.Violations()) != 1 || validationErr.Violations()[0].Code() != tt.wantCode {
+				t.Errorf("Validate() error = %v, want %s", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestProperties_ValidateReportsAllViolations(t *testing.T) {
+	p := Properties{User: "root", Pool: PoolProperties{MaxOpen: -1}, Startup: StartupProperties{MaxAttempts: -1}}
+
+	var validationErr *merrors.ValidationError
+	if err := p.Validate(); !stderrors.As(err, &validationErr) {
+		t.Fatalf("Validate() error = %v, want a ValidationError", err)
+	}
+	var codes []string
+	for _, violation := range validationErr.Violations() {
+		codes = append(codes, violation.Code())
+	}
+	want := []string{merrors.CodeRequired, merrors.CodeMin, merrors.CodeMin}
+	if !reflect.DeepEqual(codes, want) {
+		t.Errorf("violation codes = %v, want %v", codes, want)
+	}
+}
+
+func TestProperties_OpenDB(t *testing.T) {
+	p := Properties{
+		Host: "localhost",
+		User: "root",
+		Pool: PoolProperties{MaxOpen: 7, MaxIdle: 3, MaxLifetime: time.Minute},
+	}
+	db, err := p.OpenDB(context.Background())
+	if err != nil {
+		t.Fatalf("OpenDB() error = %v", err)
+	}
+	defer db.Close()
+	if got := db.Stats().MaxOpenConnections; got != 7 {
+		t.Errorf("MaxOpenConnections = %d, want 7", got)
+	}
+
+	if _, err := (&Properties{User: "root"}).OpenDB(context.Background()); err == nil {
+		t.Error("OpenDB() of invalid properties succeeded")
+	}
+}
+
+func TestProperties_OpenDBPingRetry(t *testing.T) {
+	// Reserve a port and close it, so that connecting is refused.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	p := Properties{
+		Host:    "127.0.0.1",
+		Port:    port,
+		User:    "root",
+		Startup: StartupProperties{Ping: true, MaxAttempts: 2, Interval: time.Millisecond},
+	}
+	_, err = p.OpenDB(context.Background())
+	if !stderrors.Is(err, support.ErrTransientDataAccess) {
+		t.Errorf("OpenDB() error = %v, want a connection error", err)
+	}
+}
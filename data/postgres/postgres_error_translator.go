@@ -0,0 +1,161 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"regexp"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+	"github.com/zbum/mantyboot/data"
+	"github.com/zbum/mantyboot/data/support"
+	merrors "github.com/zbum/mantyboot/errors"
+)
+
+// pgError holds the fields pgx and lib/pq both report.
+type pgError struct {
+	code       string
+	message    string
+	detail     string
+	table      string
+	column     string
+	constraint string
+}
+
+var (
+	// Key (email)=(a@example.com) already exists.
+	keyDetailPattern = regexp.MustCompile(`^Key \((.+?)\)=\((.*)\)`)
+	// Key (user_id)=(42) is not present in table "users".
+	notPresentPattern = regexp.MustCompile(`is not present in table "([^"]+)"`)
+)
+
+type PostgresErrorTranslator struct {
+}
+
+func (t PostgresErrorTranslator) TranslateExceptionIfPossible(err error) support.DataAccessError {
+	if err == nil {
+		return nil
+	}
+
+	pgErr, ok := asPgError(err)
+	if !ok {
+		if translated, ok := translateConnectionError(err); ok {
+			return translated
+		}
+		return merrors.WrapDatabaseError(err, "unknown", "failed to translate PostgreSQL error")
+	}
+
+	switch {
+	case pgErr.code == "23505": // unique_violation
+		column, value := parseKeyDetail(pgErr.detail)
+		return support.DuplicateKeyError{
+			Table:   pgErr.table,
+			Key:     pgErr.constraint,
+			Column:  column,
+			Value:   value,
+			Message: pgErr.message,
+		}
+	case pgErr.code == "23503": // foreign_key_violation
+		column, _ := parseKeyDetail(pgErr.detail)
+		var referencedTable string
+		if matches := notPresentPattern.FindStringSubmatch(pgErr.detail); matches != nil {
+			referencedTable = matches[1]
+		}
+		return support.FkConstraintError{
+			Table:           pgErr.table,
+			Constraint:      pgErr.constraint,
+			Column:          column,
+			ReferencedTable: referencedTable,
+			Message:         pgErr.message,
+		}
+	case pgErr.code == "23502": // not_null_violation
+		return support.NotNullError{
+			Table:   pgErr.table,
+			Column:  pgErr.column,
+			Message: pgErr.message,
+		}
+	case pgErr.code == "40P01": // deadlock_detected
+		return support.DeadlockError{Message: pgErr.message}
+	case pgErr.code == "40001": // serialization_failure
+		return support.SerializationFailureError{Message: pgErr.message}
+	case pgErr.code == "57014": // query_canceled, also raised by statement_timeout
+		return support.QueryTimeoutError{Message: pgErr.message}
+	default:
+		if translated, ok := support.TranslateSqlState(pgErr.code, pgErr.message); ok {
+			return translated
+		}
+		return merrors.WrapDatabaseError(err, "unknown", "unhandled PostgreSQL error "+pgErr.code)
+	}
+}
+
+// translateConnectionError translates the errors of connections that broke
+// outside of the PostgreSQL protocol. Failed connects, driver.ErrBadConn and
+// errors pgx reports as safe to retry guarantee that nothing reached the
+// server. Any other broken connection may have lost the reply to a statement
+// that was applied.
+func translateConnectionError(err error) (support.DataAccessError, bool) {
+	var connectErr *pgconn.ConnectError
+	var opErr *net.OpError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		// Context errors implement net.Error, but callers check for them as they are.
+		return err, true
+	case errors.As(err, &connectErr), errors.Is(err, driver.ErrBadConn), pgconn.SafeToRetry(err),
+		errors.As(err, &opErr) && opErr.Op == "dial":
+		return support.ConnectionError{
+			Message: err.Error(),
+			Timeout: errors.As(err, &netErr) && netErr.Timeout(),
+		}, true
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return support.QueryTimeoutError{Message: err.Error()}, true
+	case errors.As(err, &netErr):
+		return support.ConnectionLostError{Message: err.Error()}, true
+	}
+	return nil, false
+}
+
+func asPgError(err error) (pgError, bool) {
+	var pgxErr *pgconn.PgError
+	if errors.As(err, &pgxErr) {
+		return pgError{
+			code:       pgxErr.Code,
+			message:    pgxErr.Message,
+			detail:     pgxErr.Detail,
+			table:      pgxErr.TableName,
+			column:     pgxErr.ColumnName,
+			constraint: pgxErr.ConstraintName,
+		}, true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pgError{
+			code:       string(pqErr.Code),
+			message:    pqErr.Message,
+			detail:     pqErr.Detail,
+			table:      pqErr.Table,
+			column:     pqErr.Column,
+			constraint: pqErr.Constraint,
+		}, true
+	}
+	return pgError{}, false
+}
+
+// parseKeyDetail reads the column list and value of a detail such as
+// "Key (email)=(a@example.com) already exists.".
+func parseKeyDetail(detail string) (string, string) {
+	matches := keyDetailPattern.FindStringSubmatch(detail)
+	if matches == nil {
+		return "", ""
+	}
+	return matches[1], matches[2]
+}
+
+func init() {
+	// lib/pq registers "postgres", pgx/v5/stdlib registers "pgx".
+	data.RegisterTranslator("postgres", PostgresErrorTranslator{})
+	data.RegisterTranslator("pgx", PostgresErrorTranslator{})
+}
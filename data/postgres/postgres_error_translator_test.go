@@ -0,0 +1,241 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	stderrors "errors"
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+	"github.com/zbum/mantyboot/data/support"
+	merrors "github.com/zbum/mantyboot/errors"
+)
+
+func TestPostgresErrorTranslator_TranslateExceptionIfPossible(t1 *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want support.DataAccessError
+	}{
+		{
+			name: "pgx unique violation",
+			err: &pgconn.PgError{
+				Code:           "23505",
+				Message:        `duplicate key value violates unique constraint "users_email_key"`,
+				Detail:         "Key (email)=(kim@example.com) already exists.",
+				TableName:      "users",
+				ConstraintName: "users_email_key",
+			},
+			want: support.DuplicateKeyError{
+				Table:   "users",
+				Key:     "users_email_key",
+				Column:  "email",
+				Value:   "kim@example.com",
+				Message: `duplicate key value violates unique constraint "users_email_key"`,
+			},
+		},
+		{
+			name: "pq unique violation with composite key",
+			err: fmt.Errorf("insert: %w", &pq.Error{
+				Code:       "23505",
+				Message:    `duplicate key value violates unique constraint "members_pkey"`,
+				Detail:     "Key (team_id, user_id)=(1, 42) already exists.",
+				Table:      "members",
+				Constraint: "members_pkey",
+			}),
+			want: support.DuplicateKeyError{
+				Table:   "members",
+				Key:     "members_pkey",
+				Column:  "team_id, user_id",
+				Value:   "1, 42",
+				Message: `duplicate key value violates unique constraint "members_pkey"`,
+			},
+		},
+		{
+			name: "foreign key violation",
+			err: &pgconn.PgError{
+				Code:           "23503",
+				Message:        `insert or update on table "orders" violates foreign key constraint "orders_user_id_fkey"`,
+				Detail:         `Key (user_id)=(42) is not present in table "users".`,
+				TableName:      "orders",
+				ConstraintName: "orders_user_id_fkey",
+			},
+			want: support.FkConstraintError{
+				Table:           "orders",
+				Constraint:      "orders_user_id_fkey",
+				Column:          "user_id",
+				ReferencedTable: "users",
+				Message:         `insert or update on table "orders" violates foreign key constraint "orders_user_id_fkey"`,
+			},
+		},
+		{
+			name: "not null violation",
+			err: &pq.Error{
+				Code:    "23502",
+				Message: `null value in column "name" of relation "users" violates not-null constraint`,
+				Table:   "users",
+				Column:  "name",
+			},
+			want: support.NotNullError{
+				Table:   "users",
+				Column:  "name",
+				Message: `null value in column "name" of relation "users" violates not-null constraint`,
+			},
+		},
+		{
+			name: "deadlock",
+			err:  &pgconn.PgError{Code: "40P01", Message: "deadlock detected"},
+			want: support.DeadlockError{Message: "deadlock detected"},
+		},
+		{
+			name: "serialization failure",
+			err:  &pgconn.PgError{Code: "40001", Message: "could not serialize access due to concurrent update"},
+			want: support.SerializationFailureError{Message: "could not serialize access due to concurrent update"},
+		},
+		{
+			name: "statement timeout",
+			err:  &pq.Error{Code: "57014", Message: "canceling statement due to statement timeout"},
+			want: support.QueryTimeoutError{Message: "canceling statement due to statement timeout"},
+		},
+		{
+			name: "connection failure",
+			err:  &pgconn.PgError{Code: "08006", Message: "connection failure"},
+			want: support.ConnectionLostError{Message: "connection failure"},
+		},
+		{
+			name: "connection not established",
+			err:  &pq.Error{Code: "08001", Message: "could not connect to server"},
+			want: support.ConnectionError{Message: "could not connect to server"},
+		},
+		{
+			name: "bad connection",
+			err:  fmt.Errorf("query: %w", driver.ErrBadConn),
+			want: support.ConnectionError{Message: "query: driver: bad connection"},
+		},
+		{
+			name: "safe to retry",
+			err:  safeToRetryError{},
+			want: support.ConnectionError{Message: "write failed"},
+		},
+		{
+			name: "network error",
+			err:  &net.OpError{Op: "dial", Net: "tcp", Err: stderrors.New("connection refused")},
+			want: support.ConnectionError{Message: "dial tcp: connection refused"},
+		},
+		{
+			name: "network error after dial",
+			err:  &net.OpError{Op: "read", Net: "tcp", Err: stderrors.New("connection reset by peer")},
+			want: support.ConnectionLostError{Message: "read tcp: connection reset by peer"},
+		},
+		{
+			name: "read timeout",
+			err:  &net.OpError{Op: "read", Net: "tcp", Err: timeoutError{}},
+			want: support.QueryTimeoutError{Message: "read tcp: i/o timeout"},
+		},
+		{
+			name: "canceled",
+			err:  context.Canceled,
+			want: context.Canceled,
+		},
+		{
+			name: "undefined table falls back to the SQLSTATE class",
+			err:  &pgconn.PgError{Code: "42P01", Message: `relation "userz" does not exist`},
+			want: support.BadSqlGrammarError{SQLState: "42P01", Message: `relation "userz" does not exist`},
+		},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			t := PostgresErrorTranslator{}
+			if got := t.TranslateExceptionIfPossible(tt.err); !reflect.DeepEqual(got, tt.want) {
+				t1.Errorf("TranslateExceptionIfPossible() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+// safeToRetryError is a pgx error that failed before anything was sent.
+type safeToRetryError struct{}
+
+func (safeToRetryError) Error() string     { return "write failed" }
+func (safeToRetryError) SafeToRetry() bool { return true }
+
+// timeoutError is a net.Error that timed out, like the errors of deadlines
+// set on a net.Conn.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestPostgresErrorTranslator_ConnectRefused(t1 *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t1.Skipf("cannot listen: %v", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	_, err = pgconn.Connect(context.Background(), "postgres://user@"+address+"/db?sslmode=disable")
+	if err == nil {
+		t1.Fatal("Connect() succeeded on a closed port")
+	}
+
+	got := PostgresErrorTranslator{}.TranslateExceptionIfPossible(err)
+	if _, ok := got.(support.ConnectionError); !ok || !merrors.IsRetryable(got) {
+		t1.Errorf("TranslateExceptionIfPossible() = %#v, want a retryable ConnectionError", got)
+	}
+}
+
+func TestPostgresErrorTranslator_Unhandled(t1 *testing.T) {
+	t := PostgresErrorTranslator{}
+
+	if got := t.TranslateExceptionIfPossible(nil); got != nil {
+		t1.Errorf("TranslateExceptionIfPossible(nil) = %v", got)
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "unknown code", err: &pgconn.PgError{Code: "XX000", Message: "internal error"}, want: "unhandled PostgreSQL error XX000"},
+		{name: "not a postgres error", err: fmt.Errorf("boom"), want: "failed to translate PostgreSQL error"},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			var dbErr merrors.DatabaseError
+			got := t.TranslateExceptionIfPossible(tt.err)
+			if !stderrors.As(got, &dbErr) || dbErr.Message != tt.want {
+				t1.Errorf("TranslateExceptionIfPossible() = %v, want a DatabaseError %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPostgresErrorTranslator_Classification(t1 *testing.T) {
+	t := PostgresErrorTranslator{}
+	tests := []struct {
+		code      string
+		predicate func(error) bool
+	}{
+		{code: "23505", predicate: merrors.IsConflict},
+		{code: "23503", predicate: merrors.IsConflict},
+		{code: "40P01", predicate: merrors.IsRetryable},
+		{code: "40001", predicate: merrors.IsRetryable},
+		{code: "57014", predicate: merrors.IsTimeout},
+		{code: "08003", predicate: merrors.IsTransient},
+		{code: "08001", predicate: merrors.IsRetryable},
+		{code: "08006", predicate: func(err error) bool { return !merrors.IsRetryable(err) }},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.code, func(t1 *testing.T) {
+			if err := t.TranslateExceptionIfPossible(&pgconn.PgError{Code: tt.code}); !tt.predicate(err) {
+				t1.Errorf("predicate failed for SQLSTATE %s: %v", tt.code, err)
+			}
+		})
+	}
+}
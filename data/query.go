@@ -0,0 +1,200 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zbum/mantyboot/data/support"
+)
+
+// Querier is implemented by DB, Tx and Conn. A DB runs the query in the
+// transaction of ctx, if any.
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*Rows, error)
+}
+
+// QueryOne returns the single row of query. No rows is a
+// support.EmptyResultError and more than one is a
+// support.IncorrectResultSizeError.
+//
+// Struct types are mapped by column name: a field tagged `db:"name"` matches
+// the column name, an untagged field matches its own name ignoring case and
+// `db:"-"` skips the field. Fields of embedded structs are mapped as if they
+// belonged to the outer struct. Pointer fields receive nil for NULL. Every
+// column must have a field. Other types, including sql.Scanner
+// implementations and time.Time, are scanned from a single column.
+func QueryOne[T any](ctx context.Context, q Querier, query string, args ...interface{}) (T, error) {
+	return queryOne[T](ctx, q, scanRow, query, args)
+}
+
+// QueryList returns every row of query, mapped like QueryOne. It returns an
+// empty slice when there are no rows.
+func QueryList[T any](ctx context.Context, q Querier, query string, args ...interface{}) ([]T, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]T, 0)
+	for rows.Next() {
+		var result T
+		if err := scanRow(rows, &result); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// QueryScalar returns the single column of the single row of query, such as
+// the result of SELECT COUNT(*). Result sizes are checked like QueryOne.
+func QueryScalar[T any](ctx context.Context, q Querier, query string, args ...interface{}) (T, error) {
+	return queryOne[T](ctx, q, scanScalar, query, args)
+}
+
+func queryOne[T any](ctx context.Context, q Querier, scan func(*Rows, interface{}) error, query string, args []interface{}) (T, error) {
+	var result T
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return result, err
+		}
+		return result, support.EmptyResultError{Expected: 1}
+	}
+	if err := scan(rows, &result); err != nil {
+		return result, err
+	}
+
+	// Stop at the second row, so an unbounded query does not read the whole table.
+	if rows.Next() {
+		var zero T
+		return zero, support.IncorrectResultSizeError{Expected: 1, Actual: 2, AtLeast: true}
+	}
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+func scanScalar(rows *Rows, dest interface{}) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if len(columns) != 1 {
+		return fmt.Errorf("data: cannot scan %d columns into %s", len(columns), reflect.TypeOf(dest).Elem())
+	}
+	return rows.Scan(dest)
+}
+
+func scanRow(rows *Rows, dest interface{}) error {
+	value := reflect.ValueOf(dest).Elem()
+	if !isStructRow(value.Type()) {
+		return scanScalar(rows, dest)
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	fields := fieldsOf(value.Type())
+	targets := make([]interface{}, len(columns))
+	for i, column := range columns {
+		index, ok := fields[strings.ToLower(column)]
+		if !ok {
+			return fmt.Errorf("data: no field for column %q in %s", column, value.Type())
+		}
+		targets[i] = fieldByIndex(value, index).Addr().Interface()
+	}
+	return rows.Scan(targets...)
+}
+
+var (
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// isStructRow reports whether rows are mapped to the fields of typ rather
+// than scanned into it.
+func isStructRow(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Struct && typ != timeType && !reflect.PointerTo(typ).Implements(scannerType)
+}
+
+var fieldCache sync.Map // reflect.Type -> map[string][]int
+
+// fieldsOf returns the index paths of the fields of typ by lower-case column name.
+func fieldsOf(typ reflect.Type) map[string][]int {
+	if cached, ok := fieldCache.Load(typ); ok {
+		return cached.(map[string][]int)
+	}
+	fields := make(map[string][]int)
+	collectFields(typ, nil, fields)
+	fieldCache.Store(typ, fields)
+	return fields
+}
+
+func collectFields(typ reflect.Type, parent []int, fields map[string][]int) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("db")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		index := append(append([]int(nil), parent...), i)
+
+		embedded := field.Type
+		if embedded.Kind() == reflect.Pointer {
+			// A nil pointer to an unexported struct cannot be allocated.
+			if !field.IsExported() {
+				continue
+			}
+			embedded = embedded.Elem()
+		}
+		if field.Anonymous && tag == "" && isStructRow(embedded) {
+			collectFields(embedded, index, fields)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		name := tag
+		if name == "" {
+			name = field.Name
+		}
+		name = strings.ToLower(name)
+		// Fields of the outer struct win over those of embedded structs.
+		if existing, ok := fields[name]; !ok || len(existing) > len(index) {
+			fields[name] = index
+		}
+	}
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex, allocating nil embedded pointers.
+func fieldByIndex(value reflect.Value, index []int) reflect.Value {
+	for i, position := range index {
+		if i > 0 && value.Kind() == reflect.Pointer {
+			if value.IsNil() {
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+			value = value.Elem()
+		}
+		value = value.Field(position)
+	}
+	return value
+}
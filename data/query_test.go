@@ -0,0 +1,175 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zbum/mantyboot/data/support"
+	merrors "github.com/zbum/mantyboot/errors"
+)
+
+type auditing struct {
+	CreatedAt time.Time  `db:"created_at"`
+	DeletedAt *time.Time `db:"deleted_at"`
+}
+
+type user struct {
+	auditing
+	ID       int64          `db:"id"`
+	Email    string         `db:"email"`
+	Nickname *string        `db:"nickname"`
+	Phone    sql.NullString `db:"phone"`
+	Status   status
+	Ignored  string `db:"-"`
+}
+
+// status implements sql.Scanner.
+type status struct {
+	active bool
+}
+
+func (s *status) Scan(src interface{}) error {
+	s.active = src == "ACTIVE"
+	return nil
+}
+
+var (
+	userColumns = []string{"id", "email", "nickname", "phone", "status", "created_at", "deleted_at"}
+	created     = time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+)
+
+func TestQueryOne(t *testing.T) {
+	db, server := newFakeDB(t, "fakedb")
+	server.on("SELECT user", fakeResult{columns: userColumns, rows: [][]driver.Value{
+		{int64(1), "kim@example.com", "kim", nil, "ACTIVE", created, nil},
+	}})
+
+	got, err := QueryOne[user](context.Background(), db, "SELECT user")
+	if err != nil {
+		t.Fatalf("QueryOne() error = %v", err)
+	}
+	nickname := "kim"
+	want := user{
+		auditing: auditing{CreatedAt: created},
+		ID:       1,
+		Email:    "kim@example.com",
+		Nickname: &nickname,
+		Status:   status{active: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("QueryOne() = %+v, want %+v", got, want)
+	}
+}
+
+func TestQueryOne_ResultSize(t *testing.T) {
+	db, server := newFakeDB(t, "fakedb")
+	server.on("SELECT none", fakeResult{columns: []string{"id"}})
+	server.on("SELECT many", fakeResult{columns: []string{"id"}, rows: [][]driver.Value{{int64(1)}, {int64(2)}, {int64(3)}}})
+	ctx := context.Background()
+
+	_, err := QueryOne[int64](ctx, db, "SELECT none")
+	if !errors.Is(err, support.ErrEmptyResult) || !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("QueryOne() error = %v, want an empty result", err)
+	}
+
+	_, err = QueryOne[int64](ctx, db, "SELECT many")
+	var sizeErr support.IncorrectResultSizeError
+	if !errors.As(err, &sizeErr) || sizeErr.Actual != 2 || !sizeErr.AtLeast || errors.Is(err, support.ErrEmptyResult) {
+		t.Errorf("QueryOne() error = %v, want an incorrect result size of at least 2", err)
+	}
+	if !merrors.IsConflict(err) {
+		t.Errorf("QueryOne() error = %v, want a conflict", err)
+	}
+}
+
+func TestQueryList(t *testing.T) {
+	type item struct {
+		ID   int64
+		Name string `db:"item_name"`
+	}
+	db, server := newFakeDB(t, "fakedb")
+	server.on("SELECT items", fakeResult{columns: []string{"ID", "item_name"}, rows: [][]driver.Value{
+		{int64(1), "apple"},
+		{int64(2), "pear"},
+	}})
+	server.on("SELECT nothing", fakeResult{columns: []string{"id", "item_name"}})
+	ctx := context.Background()
+
+	got, err := QueryList[item](ctx, db, "SELECT items")
+	if want := []item{{1, "apple"}, {2, "pear"}}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("QueryList() = %v, %v, want %v", got, err, want)
+	}
+
+	got, err = QueryList[item](ctx, db, "SELECT nothing")
+	if err != nil || got == nil || len(got) != 0 {
+		t.Errorf("QueryList() = %#v, %v, want an empty slice", got, err)
+	}
+}
+
+func TestQueryScalar(t *testing.T) {
+	db, server := newFakeDB(t, "fakedb")
+	server.on("SELECT COUNT(*)", fakeResult{columns: []string{"count"}, rows: [][]driver.Value{{int64(42)}}})
+	server.on("SELECT MAX(created_at)", fakeResult{columns: []string{"max"}, rows: [][]driver.Value{{created}}})
+	server.on("SELECT id, email", fakeResult{columns: []string{"id", "email"}, rows: [][]driver.Value{{int64(1), "kim@example.com"}}})
+	ctx := context.Background()
+
+	if got, err := QueryScalar[int](ctx, db, "SELECT COUNT(*)"); err != nil || got != 42 {
+		t.Errorf("QueryScalar() = %v, %v, want 42", got, err)
+	}
+	if got, err := QueryScalar[time.Time](ctx, db, "SELECT MAX(created_at)"); err != nil || !got.Equal(created) {
+		t.Errorf("QueryScalar() = %v, %v, want %v", got, err, created)
+	}
+	if _, err := QueryScalar[int](ctx, db, "SELECT id, email"); err == nil || !strings.Contains(err.Error(), "2 columns") {
+		t.Errorf("QueryScalar() error = %v, want a column count error", err)
+	}
+}
+
+func TestQuery_Errors(t *testing.T) {
+	db, server := newFakeDB(t, "fakedb")
+	server.on("SELECT bad", fakeResult{err: stateError{"42S22"}})
+	server.on("SELECT extra", fakeResult{columns: []string{"id", "unknown"}, rows: [][]driver.Value{{int64(1), "x"}}})
+	ctx := context.Background()
+
+	if _, err := QueryList[user](ctx, db, "SELECT bad"); !errors.Is(err, support.ErrBadSqlGrammar) {
+		t.Errorf("QueryList() error = %v, want a translated error", err)
+	}
+	if _, err := QueryOne[user](ctx, db, "SELECT extra"); err == nil || !strings.Contains(err.Error(), `column "unknown"`) {
+		t.Errorf("QueryOne() error = %v, want an unmapped column error", err)
+	}
+}
+
+func TestQuery_InTransaction(t *testing.T) {
+	db, server := newFakeDB(t, "fakedb")
+	server.on("SELECT COUNT(*)", fakeResult{columns: []string{"count"}, rows: [][]driver.Value{{int64(1)}}})
+
+	NewTxManager(db).RunInTx(context.Background(), nil, func(ctx context.Context) error {
+		_, err := QueryScalar[int64](ctx, db, "SELECT COUNT(*)")
+		return err
+	})
+	if got := server.statements(); got != "BEGIN; SELECT COUNT(*); COMMIT" {
+		t.Errorf("statements = %q", got)
+	}
+}
+
+func TestQueryOne_EmbeddedPointer(t *testing.T) {
+	type Auditing auditing
+	type post struct {
+		*Auditing
+		ID int64 `db:"id"`
+	}
+	db, server := newFakeDB(t, "fakedb")
+	server.on("SELECT post", fakeResult{columns: []string{"id", "created_at", "deleted_at"}, rows: [][]driver.Value{
+		{int64(7), created, created},
+	}})
+
+	got, err := QueryOne[post](context.Background(), db, "SELECT post")
+	if err != nil || got.Auditing == nil || !got.CreatedAt.Equal(created) || got.DeletedAt == nil {
+		t.Errorf("QueryOne() = %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,72 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+
+	"github.com/zbum/mantyboot/data/support"
+)
+
+var (
+	translatorsMu sync.RWMutex
+	translators   = make(map[string]support.PersistenceErrorTranslator)
+)
+
+// RegisterTranslator makes a translator available for a database/sql driver
+// name. Driver packages such as data/mysql register theirs in init, so a blank
+// import is enough:
+//
+//	import _ "github.com/zbum/mantyboot/data/mysql"
+func RegisterTranslator(driverName string, translator support.PersistenceErrorTranslator) {
+	if translator == nil {
+		panic("data: RegisterTranslator translator is nil")
+	}
+	translatorsMu.Lock()
+	defer translatorsMu.Unlock()
+	translators[driverName] = translator
+}
+
+// Translator returns the translator registered for driverName, or the
+// SQLSTATE based translator when there is none.
+func Translator(driverName string) support.PersistenceErrorTranslator {
+	translatorsMu.RLock()
+	defer translatorsMu.RUnlock()
+	if translator, ok := translators[driverName]; ok {
+		return translator
+	}
+	return support.SqlStateErrorTranslator{}
+}
+
+// translate passes err through translator. Errors callers check by identity,
+// such as sql.ErrNoRows and context cancellation, are returned unchanged. The
+// translated error wraps err as well.
+func translate(translator support.PersistenceErrorTranslator, err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) || errors.Is(err, sql.ErrTxDone) || errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	translated := translator.TranslateExceptionIfPossible(err)
+	if translated == nil {
+		return err
+	}
+	if errors.Is(translated, err) {
+		return translated
+	}
+	return translatedError{error: translated, cause: err}
+}
+
+// translatedError keeps the driver error in the chain of its translation, so
+// that errors.As still finds driver types such as *mysql.MySQLError.
+type translatedError struct {
+	error
+	cause error
+}
+
+func (e translatedError) Unwrap() []error {
+	return []error{e.error, e.cause}
+}
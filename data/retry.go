@@ -0,0 +1,101 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	merrors "github.com/zbum/mantyboot/errors"
+)
+
+// RetryPolicy retries operations that failed with a transient error, such as
+// a deadlock, a lock wait timeout or a lost connection, with exponential
+// backoff.
+type RetryPolicy struct {
+	MaxAttempts     int
+	InitialInterval time.Duration
+	Multiplier      float64
+	MaxInterval     time.Duration
+	// Jitter shortens each interval by a random fraction of up to Jitter (0 to 1),
+	// so that clients that failed together do not retry together.
+	Jitter float64
+
+	// Retryable decides which errors are retried. It defaults to errors.IsRetryable.
+	Retryable func(err error) bool
+	// OnRetry is called before waiting for the next attempt.
+	OnRetry func(attempt int, err error, delay time.Duration)
+
+	random func() float64
+}
+
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:     3,
+		InitialInterval: 50 * time.Millisecond,
+		Multiplier:      2,
+		MaxInterval:     time.Second,
+		Jitter:          0.5,
+	}
+}
+
+// Do calls fn until it succeeds, fails with an error that is not retryable or
+// runs out of attempts. It does not wait past the deadline of ctx.
+func (p *RetryPolicy) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	attempts := p.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = merrors.IsRetryable
+	}
+	interval := p.InitialInterval
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		err = fn(ctx)
+		if err == nil || !retryable(err) || attempt == attempts {
+			break
+		}
+
+		delay := p.jitter(interval)
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+			break
+		}
+		if p.OnRetry != nil {
+			p.OnRetry(attempt, err, delay)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+
+		if p.Multiplier > 0 {
+			interval = time.Duration(float64(interval) * p.Multiplier)
+		}
+		if p.MaxInterval > 0 && interval > p.MaxInterval {
+			interval = p.MaxInterval
+		}
+	}
+	return err
+}
+
+func (p *RetryPolicy) jitter(interval time.Duration) time.Duration {
+	if p.Jitter <= 0 {
+		return interval
+	}
+	random := p.random
+	if random == nil {
+		random = rand.Float64
+	}
+	jitter := p.Jitter
+	if jitter > 1 {
+		jitter = 1
+	}
+	return interval - time.Duration(float64(interval)*jitter*random())
+}
@@ -0,0 +1,130 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zbum/mantyboot/data/support"
+)
+
+func TestRetryPolicy_Do(t *testing.T) {
+	deadlock := support.DeadlockError{Message: "Deadlock found when trying to get lock"}
+	tests := []struct {
+		name         string
+		errs         []error
+		wantErr      error
+		wantAttempts int
+	}{
+		{name: "success", errs: []error{nil}, wantAttempts: 1},
+		{name: "deadlock then success", errs: []error{deadlock, nil}, wantAttempts: 2},
+		{name: "lock wait timeout then connection error then success", errs: []error{support.LockTimeoutError{}, support.ConnectionError{}, nil}, wantAttempts: 3},
+		{name: "out of attempts", errs: []error{deadlock, deadlock, deadlock, nil}, wantErr: deadlock, wantAttempts: 3},
+		{name: "not retryable", errs: []error{support.DuplicateKeyError{}, nil}, wantErr: support.DuplicateKeyError{}, wantAttempts: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var delays []time.Duration
+			policy := &RetryPolicy{
+				MaxAttempts:     3,
+				InitialInterval: time.Millisecond,
+				Multiplier:      2,
+				OnRetry: func(attempt int, err error, delay time.Duration) {
+					delays = append(delays, delay)
+				},
+			}
+
+			attempts := 0
+			err := policy.Do(context.Background(), func(ctx context.Context) error {
+				attempts++
+				return tt.errs[attempts-1]
+			})
+			if err != tt.wantErr {
+				t.Errorf("Do() error = %v, want %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts || len(delays) != attempts-1 {
+				t.Errorf("attempts = %d, retries reported = %d, want %d attempts", attempts, len(delays), tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	var delays []time.Duration
+	policy := &RetryPolicy{
+		MaxAttempts:     5,
+		InitialInterval: time.Millisecond,
+		Multiplier:      2,
+		MaxInterval:     3 * time.Millisecond,
+		Jitter:          0.5,
+		OnRetry: func(attempt int, err error, delay time.Duration) {
+			delays = append(delays, delay)
+		},
+		random: func() float64 { return 1 },
+	}
+
+	policy.Do(context.Background(), func(ctx context.Context) error {
+		return support.DeadlockError{}
+	})
+
+	want := []time.Duration{500 * time.Microsecond, time.Millisecond, 1500 * time.Microsecond, 1500 * time.Microsecond}
+	if len(delays) != len(want) {
+		t.Fatalf("delays = %v, want %v", delays, want)
+	}
+	for i := range want {
+		if delays[i] != want[i] {
+			t.Errorf("delays = %v, want %v", delays, want)
+			break
+		}
+	}
+}
+
+func TestRetryPolicy_Deadline(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 5, InitialInterval: time.Hour}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	attempts := 0
+	start := time.Now()
+	err := policy.Do(ctx, func(ctx context.Context) error {
+		attempts++
+		return support.DeadlockError{}
+	})
+	if attempts != 1 || !errors.Is(err, support.ErrTransientDataAccess) || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("Do() = %v after %d attempts, want to give up before the deadline", err, attempts)
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	policy = &RetryPolicy{MaxAttempts: 5, InitialInterval: time.Hour, OnRetry: func(int, error, time.Duration) { cancel() }}
+	err = policy.Do(canceled, func(ctx context.Context) error {
+		return support.DeadlockError{}
+	})
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, support.ErrTransientDataAccess) {
+		t.Errorf("Do() error = %v, want the last error and context.Canceled", err)
+	}
+}
+
+func TestTxManager_RunInTxRetry(t *testing.T) {
+	db, server := newFakeDB(t, "fakedb")
+	m := NewTxManager(db)
+	retries := 0
+	policy := &RetryPolicy{MaxAttempts: 3, OnRetry: func(int, error, time.Duration) { retries++ }}
+
+	attempts := 0
+	err := m.RunInTx(context.Background(), &TxOptions{Retry: policy}, func(ctx context.Context) error {
+		attempts++
+		db.ExecContext(ctx, "UPDATE stock")
+		if attempts == 1 {
+			return support.DeadlockError{}
+		}
+		return nil
+	})
+
+	if err != nil || retries != 1 {
+		t.Errorf("RunInTx() error = %v, retries = %d", err, retries)
+	}
+	if got, want := server.statements(), "BEGIN; UPDATE stock; ROLLBACK; BEGIN; UPDATE stock; COMMIT"; got != want {
+		t.Errorf("statements = %q, want %q", got, want)
+	}
+}
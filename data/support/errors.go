@@ -0,0 +1,491 @@
+package support
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	merrors "github.com/zbum/mantyboot/errors"
+)
+
+// Categories of data access errors. Every error type below matches its
+// categories with errors.Is, so callers can handle a whole group without
+// importing a driver package:
+//
+//	if errors.Is(err, support.ErrDataIntegrityViolation) { ... }
+var (
+	ErrDataAccess               = errors.New("data access error")
+	ErrDataIntegrityViolation   = errors.New("data integrity violation")
+	ErrTransientDataAccess      = errors.New("transient data access error")
+	ErrOptimisticLockingFailure = errors.New("optimistic locking failure")
+	ErrIncorrectResultSize      = errors.New("incorrect result size")
+	ErrEmptyResult              = errors.New("empty result")
+	ErrBadSqlGrammar            = errors.New("bad SQL grammar")
+)
+
+// isCategory reports whether target is ErrDataAccess or one of categories.
+func isCategory(target error, categories ...error) bool {
+	if target == ErrDataAccess {
+		return true
+	}
+	for _, category := range categories {
+		if target == category {
+			return true
+		}
+	}
+	return false
+}
+
+// Data integrity violations
+
+type DuplicateKeyError struct {
+	Table string
+	Key   string
+	// Column is the key column list when the driver reports it, as
+	// PostgreSQL does. MySQL messages name only the key, so it stays empty.
+	Column  string
+	Value   string
+	Message string
+}
+
+func (d DuplicateKeyError) Error() string {
+	switch {
+	case d.Table != "" && d.Key != "":
+		return fmt.Sprintf("duplicate key error on table '%s' key '%s': %s", d.Table, d.Key, d.Message)
+	case d.Table != "" && d.Column != "":
+		return fmt.Sprintf("duplicate key error on table '%s' column '%s': %s", d.Table, d.Column, d.Message)
+	case d.Key != "":
+		return fmt.Sprintf("duplicate key error on key '%s': %s", d.Key, d.Message)
+	}
+	return fmt.Sprintf("duplicate key error: %s", d.Message)
+}
+
+func (d DuplicateKeyError) Is(target error) bool {
+	return isCategory(target, ErrDataIntegrityViolation)
+}
+
+func (d DuplicateKeyError) Code() string {
+	return merrors.CodeDuplicateKey
+}
+
+func (d DuplicateKeyError) Classify() merrors.Classification {
+	return merrors.Conflict
+}
+
+type FkConstraintError struct {
+	Table            string
+	Constraint       string
+	Column           string
+	ReferencedTable  string
+	ReferencedColumn string
+	Message          string
+}
+
+func (d FkConstraintError) Error() string {
+	if d.Table != "" && d.Constraint != "" {
+		return fmt.Sprintf("foreign key constraint error on table '%s' constraint '%s': %s", d.Table, d.Constraint, d.Message)
+	}
+	return fmt.Sprintf("foreign key constraint error: %s", d.Message)
+}
+
+func (d FkConstraintError) Is(target error) bool {
+	return isCategory(target, ErrDataIntegrityViolation)
+}
+
+func (d FkConstraintError) Code() string {
+	return merrors.CodeForeignKey
+}
+
+func (d FkConstraintError) Classify() merrors.Classification {
+	return merrors.Conflict
+}
+
+type NotNullError struct {
+	Table   string
+	Column  string
+	Message string
+}
+
+func (d NotNullError) Error() string {
+	if d.Column != "" {
+		return fmt.Sprintf("not null constraint error on column '%s': %s", d.Column, d.Message)
+	}
+	return fmt.Sprintf("not null constraint error: %s", d.Message)
+}
+
+func (d NotNullError) Is(target error) bool {
+	return isCategory(target, ErrDataIntegrityViolation)
+}
+
+func (d NotNullError) Code() string {
+	return merrors.CodeNotNull
+}
+
+func (d NotNullError) Classify() merrors.Classification {
+	return merrors.ClientError
+}
+
+// DataTooLongError is a value that does not fit its column.
+type DataTooLongError struct {
+	Table   string
+	Column  string
+	Message string
+}
+
+func (d DataTooLongError) Error() string {
+	if d.Column != "" {
+		return fmt.Sprintf("data too long for column '%s': %s", d.Column, d.Message)
+	}
+	return fmt.Sprintf("data too long: %s", d.Message)
+}
+
+func (d DataTooLongError) Is(target error) bool {
+	return isCategory(target, ErrDataIntegrityViolation)
+}
+
+func (d DataTooLongError) Code() string {
+	return merrors.CodeDataTooLong
+}
+
+func (d DataTooLongError) Classify() merrors.Classification {
+	return merrors.ClientError
+}
+
+// DataIntegrityViolationError is an integrity constraint violation (SQLSTATE
+// class 23) that no more specific type describes.
+type DataIntegrityViolationError struct {
+	SQLState string
+	Message  string
+}
+
+func (d DataIntegrityViolationError) Error() string {
+	return fmt.Sprintf("data integrity violation (%s): %s", d.SQLState, d.Message)
+}
+
+func (d DataIntegrityViolationError) Is(target error) bool {
+	return isCategory(target, ErrDataIntegrityViolation)
+}
+
+func (d DataIntegrityViolationError) Code() string {
+	return merrors.CodeDataIntegrity
+}
+
+func (d DataIntegrityViolationError) Classify() merrors.Classification {
+	return merrors.Conflict
+}
+
+// DataError is a value the database cannot store or convert (SQLSTATE class 22).
+type DataError struct {
+	SQLState string
+	Message  string
+}
+
+func (d DataError) Error() string {
+	return fmt.Sprintf("invalid data (%s): %s", d.SQLState, d.Message)
+}
+
+func (d DataError) Is(target error) bool {
+	return isCategory(target, ErrDataIntegrityViolation)
+}
+
+func (d DataError) Code() string {
+	return merrors.CodeInvalidData
+}
+
+func (d DataError) Classify() merrors.Classification {
+	return merrors.ClientError
+}
+
+// Transient data access errors. The same operation may succeed when retried.
+
+type DeadlockError struct {
+	Message string
+}
+
+func (d DeadlockError) Error() string {
+	return fmt.Sprintf("deadlock detected: %s", d.Message)
+}
+
+func (d DeadlockError) Is(target error) bool {
+	return isCategory(target, ErrTransientDataAccess)
+}
+
+func (d DeadlockError) Code() string {
+	return merrors.CodeDeadlock
+}
+
+func (d DeadlockError) Classify() merrors.Classification {
+	return merrors.Transient | merrors.Retryable
+}
+
+type LockTimeoutError struct {
+	Message string
+}
+
+func (d LockTimeoutError) Error() string {
+	return fmt.Sprintf("lock wait timeout: %s", d.Message)
+}
+
+func (d LockTimeoutError) Is(target error) bool {
+	return isCategory(target, ErrTransientDataAccess)
+}
+
+func (d LockTimeoutError) Code() string {
+	return merrors.CodeLockTimeout
+}
+
+func (d LockTimeoutError) Classify() merrors.Classification {
+	return merrors.Timeout | merrors.Transient | merrors.Retryable
+}
+
+// SerializationFailureError is returned when a serializable transaction
+// conflicts with a concurrent one and must be retried.
+type SerializationFailureError struct {
+	Message string
+}
+
+func (d SerializationFailureError) Error() string {
+	return fmt.Sprintf("could not serialize transaction: %s", d.Message)
+}
+
+func (d SerializationFailureError) Is(target error) bool {
+	return isCategory(target, ErrTransientDataAccess)
+}
+
+func (d SerializationFailureError) Code() string {
+	return merrors.CodeSerialization
+}
+
+func (d SerializationFailureError) Classify() merrors.Classification {
+	return merrors.Transient | merrors.Retryable
+}
+
+// TransactionRollbackError is a transaction rolled back by the database
+// (SQLSTATE class 40) that no more specific type describes.
+type TransactionRollbackError struct {
+	SQLState string
+	Message  string
+}
+
+func (d TransactionRollbackError) Error() string {
+	return fmt.Sprintf("transaction rolled back (%s): %s", d.SQLState, d.Message)
+}
+
+func (d TransactionRollbackError) Is(target error) bool {
+	return isCategory(target, ErrTransientDataAccess)
+}
+
+func (d TransactionRollbackError) Code() string {
+	return merrors.CodeRollback
+}
+
+func (d TransactionRollbackError) Classify() merrors.Classification {
+	return merrors.Transient | merrors.Retryable
+}
+
+type QueryTimeoutError struct {
+	Message string
+}
+
+func (d QueryTimeoutError) Error() string {
+	return fmt.Sprintf("query timed out: %s", d.Message)
+}
+
+func (d QueryTimeoutError) Is(target error) bool {
+	return isCategory(target, ErrTransientDataAccess)
+}
+
+func (d QueryTimeoutError) Code() string {
+	return merrors.CodeQueryTimeout
+}
+
+func (d QueryTimeoutError) Classify() merrors.Classification {
+	return merrors.Timeout | merrors.Transient
+}
+
+// ConnectionError is a connection that failed before the statement was sent,
+// so retrying cannot apply it twice. Timeout is set when the connection could
+// not be established in time.
+type ConnectionError struct {
+	Message string
+	Timeout bool
+}
+
+func (d ConnectionError) Error() string {
+	return fmt.Sprintf("database connection error: %s", d.Message)
+}
+
+func (d ConnectionError) Is(target error) bool {
+	return isCategory(target, ErrTransientDataAccess)
+}
+
+func (d ConnectionError) Code() string {
+	return merrors.CodeConnection
+}
+
+func (d ConnectionError) Classify() merrors.Classification {
+	if d.Timeout {
+		return merrors.Timeout | merrors.Transient | merrors.Retryable
+	}
+	return merrors.Transient | merrors.Retryable
+}
+
+// ConnectionLostError is a connection that failed after a statement may have
+// reached the server. The statement, or the COMMIT of its transaction, may
+// have been applied, so it is not retried automatically.
+type ConnectionLostError struct {
+	Message string
+}
+
+func (d ConnectionLostError) Error() string {
+	return fmt.Sprintf("database connection lost: %s", d.Message)
+}
+
+func (d ConnectionLostError) Is(target error) bool {
+	return isCategory(target, ErrTransientDataAccess)
+}
+
+func (d ConnectionLostError) Code() string {
+	return merrors.CodeConnection
+}
+
+func (d ConnectionLostError) Classify() merrors.Classification {
+	return merrors.Transient
+}
+
+// ReadOnlyError is a write rejected by a read-only server or transaction, as
+// happens on a replica or during a failover.
+type ReadOnlyError struct {
+	Message string
+}
+
+func (d ReadOnlyError) Error() string {
+	return fmt.Sprintf("database is read-only: %s", d.Message)
+}
+
+func (d ReadOnlyError) Is(target error) bool {
+	return isCategory(target, ErrTransientDataAccess)
+}
+
+func (d ReadOnlyError) Code() string {
+	return merrors.CodeReadOnly
+}
+
+func (d ReadOnlyError) Classify() merrors.Classification {
+	return merrors.Transient
+}
+
+// OptimisticLockingFailureError is returned when a versioned row was changed
+// or deleted by someone else since it was read.
+type OptimisticLockingFailureError struct {
+	Table   string
+	ID      interface{}
+	Message string
+}
+
+func (d OptimisticLockingFailureError) Error() string {
+	if d.Table != "" {
+		return fmt.Sprintf("optimistic locking failure on table '%s' id '%v': %s", d.Table, d.ID, d.Message)
+	}
+	return fmt.Sprintf("optimistic locking failure: %s", d.Message)
+}
+
+func (d OptimisticLockingFailureError) Is(target error) bool {
+	return isCategory(target, ErrOptimisticLockingFailure)
+}
+
+func (d OptimisticLockingFailureError) Code() string {
+	return merrors.CodeOptimisticLock
+}
+
+func (d OptimisticLockingFailureError) Classify() merrors.Classification {
+	return merrors.Conflict
+}
+
+// Result size errors
+
+// IncorrectResultSizeError is returned when a query expected a different
+// number of rows than it returned.
+type IncorrectResultSizeError struct {
+	Expected int
+	Actual   int
+	// AtLeast means that the remaining rows were not read, so Actual is a
+	// lower bound.
+	AtLeast bool
+}
+
+func (d IncorrectResultSizeError) Error() string {
+	if d.AtLeast {
+		return fmt.Sprintf("incorrect result size: expected %d, actual at least %d", d.Expected, d.Actual)
+	}
+	return fmt.Sprintf("incorrect result size: expected %d, actual %d", d.Expected, d.Actual)
+}
+
+func (d IncorrectResultSizeError) Is(target error) bool {
+	return isCategory(target, ErrIncorrectResultSize)
+}
+
+func (d IncorrectResultSizeError) Code() string {
+	return merrors.CodeResultSize
+}
+
+// Classify reports a conflict: the data no longer matches the single row the
+// caller expected.
+func (d IncorrectResultSizeError) Classify() merrors.Classification {
+	return merrors.Conflict
+}
+
+// EmptyResultError is an IncorrectResultSizeError for a query that returned
+// no rows.
+type EmptyResultError struct {
+	Expected int
+	Message  string
+}
+
+func (d EmptyResultError) Error() string {
+	if d.Message != "" {
+		return fmt.Sprintf("empty result: %s", d.Message)
+	}
+	return fmt.Sprintf("empty result: expected %d, actual 0", d.Expected)
+}
+
+// Is also matches sql.ErrNoRows, so code written against database/sql keeps working.
+func (d EmptyResultError) Is(target error) bool {
+	return target == sql.ErrNoRows || isCategory(target, ErrIncorrectResultSize, ErrEmptyResult)
+}
+
+func (d EmptyResultError) Code() string {
+	return merrors.CodeEmptyResult
+}
+
+func (d EmptyResultError) Classify() merrors.Classification {
+	return merrors.NotFound
+}
+
+// Bad SQL grammar
+
+type BadSqlGrammarError struct {
+	SQLState string
+	Message  string
+}
+
+func (d BadSqlGrammarError) Error() string {
+	if d.SQLState == "" {
+		return fmt.Sprintf("SQL syntax error: %s", d.Message)
+	}
+	return fmt.Sprintf("bad SQL grammar (%s): %s", d.SQLState, d.Message)
+}
+
+func (d BadSqlGrammarError) Is(target error) bool {
+	return isCategory(target, ErrBadSqlGrammar)
+}
+
+func (d BadSqlGrammarError) Code() string {
+	return merrors.CodeSQLSyntax
+}
+
+// Classify reports none of the classifications: the query itself is wrong, so
+// neither retrying nor changing the request helps.
+func (d BadSqlGrammarError) Classify() merrors.Classification {
+	return 0
+}
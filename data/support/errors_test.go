@@ -0,0 +1,61 @@
+package support
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorCategories(t *testing.T) {
+	categories := []error{
+		ErrDataIntegrityViolation,
+		ErrTransientDataAccess,
+		ErrOptimisticLockingFailure,
+		ErrIncorrectResultSize,
+		ErrEmptyResult,
+		ErrBadSqlGrammar,
+	}
+	tests := []struct {
+		err  error
+		want []error
+	}{
+		{err: DuplicateKeyError{}, want: []error{ErrDataIntegrityViolation}},
+		{err: FkConstraintError{}, want: []error{ErrDataIntegrityViolation}},
+		{err: NotNullError{}, want: []error{ErrDataIntegrityViolation}},
+		{err: DataTooLongError{}, want: []error{ErrDataIntegrityViolation}},
+		{err: DataIntegrityViolationError{}, want: []error{ErrDataIntegrityViolation}},
+		{err: DataError{}, want: []error{ErrDataIntegrityViolation}},
+		{err: DeadlockError{}, want: []error{ErrTransientDataAccess}},
+		{err: LockTimeoutError{}, want: []error{ErrTransientDataAccess}},
+		{err: SerializationFailureError{}, want: []error{ErrTransientDataAccess}},
+		{err: TransactionRollbackError{}, want: []error{ErrTransientDataAccess}},
+		{err: QueryTimeoutError{}, want: []error{ErrTransientDataAccess}},
+		{err: ConnectionError{}, want: []error{ErrTransientDataAccess}},
+		{err: ReadOnlyError{}, want: []error{ErrTransientDataAccess}},
+		{err: OptimisticLockingFailureError{}, want: []error{ErrOptimisticLockingFailure}},
+		{err: IncorrectResultSizeError{Expected: 1, Actual: 2}, want: []error{ErrIncorrectResultSize}},
+		{err: EmptyResultError{Expected: 1}, want: []error{ErrIncorrectResultSize, ErrEmptyResult}},
+		{err: BadSqlGrammarError{}, want: []error{ErrBadSqlGrammar}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%T", tt.err), func(t *testing.T) {
+			wrapped := fmt.Errorf("repository: %w", tt.err)
+			if !errors.Is(wrapped, ErrDataAccess) {
+				t.Errorf("errors.Is(%T, ErrDataAccess) = false", tt.err)
+			}
+			for _, category := range categories {
+				want := false
+				for _, w := range tt.want {
+					want = want || w == category
+				}
+				if got := errors.Is(wrapped, category); got != want {
+					t.Errorf("errors.Is(%T, %v) = %v, want %v", tt.err, category, got, want)
+				}
+			}
+		})
+	}
+
+	if errors.Is(fmt.Errorf("other"), ErrDataAccess) {
+		t.Error("an unrelated error matched ErrDataAccess")
+	}
+}
@@ -0,0 +1,75 @@
+package support
+
+import (
+	"errors"
+	"strings"
+
+	merrors "github.com/zbum/mantyboot/errors"
+)
+
+// sqlStater is implemented by driver errors that report a SQLSTATE, such as
+// *pq.Error and *pgconn.PgError.
+type sqlStater interface {
+	SQLState() string
+}
+
+// SqlStateErrorTranslator translates any driver error with a SQLState() method
+// by its SQLSTATE class. It serves drivers without a dedicated translator.
+type SqlStateErrorTranslator struct {
+}
+
+func (t SqlStateErrorTranslator) TranslateExceptionIfPossible(err error) DataAccessError {
+	if err == nil {
+		return nil
+	}
+
+	var stater sqlStater
+	if !errors.As(err, &stater) {
+		return merrors.WrapDatabaseError(err, "unknown", "failed to translate database error")
+	}
+
+	state := stater.SQLState()
+	if translated, ok := TranslateSqlState(state, err.Error()); ok {
+		return translated
+	}
+	return merrors.WrapDatabaseError(merrors.With(err, "sql_state", state), "unknown", "unhandled SQLSTATE "+state)
+}
+
+// TranslateSqlState maps a SQLSTATE to a driver independent error. It reports
+// false for states outside the classes it knows.
+func TranslateSqlState(state, message string) (DataAccessError, bool) {
+	if len(state) != 5 {
+		return nil, false
+	}
+
+	switch state {
+	case "23505":
+		return DuplicateKeyError{Message: message}, true
+	case "23503":
+		return FkConstraintError{Message: message}, true
+	case "23502":
+		return NotNullError{Message: message}, true
+	case "40001":
+		return SerializationFailureError{Message: message}, true
+	case "40P01":
+		return DeadlockError{Message: message}, true
+	case "25006":
+		return ReadOnlyError{Message: message}, true
+	case "08001", "08004": // unable to establish, rejected by the server
+		return ConnectionError{Message: message}, true
+	}
+
+	switch {
+	case strings.HasPrefix(state, "23"): // integrity constraint violation
+		return DataIntegrityViolationError{SQLState: state, Message: message}, true
+	case strings.HasPrefix(state, "40"): // transaction rollback
+		return TransactionRollbackError{SQLState: state, Message: message}, true
+	case strings.HasPrefix(state, "08"): // connection exception, possibly mid-statement
+		return ConnectionLostError{Message: message}, true
+	case strings.HasPrefix(state, "42"): // syntax error or access rule violation
+		return BadSqlGrammarError{SQLState: state, Message: message}, true
+	case strings.HasPrefix(state, "22"): // data exception
+		return DataError{SQLState: state, Message: message}, true
+	}
+	return nil, false
+}
@@ -0,0 +1,127 @@
+package support
+
+import (
+	stderrors "errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	merrors "github.com/zbum/mantyboot/errors"
+)
+
+// stateError stands in for the error of a driver without a dedicated translator.
+type stateError struct {
+	state   string
+	message string
+}
+
+func (e stateError) Error() string {
+	return e.message
+}
+
+func (e stateError) SQLState() string {
+	return e.state
+}
+
+func TestSqlStateErrorTranslator_TranslateExceptionIfPossible(t1 *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want DataAccessError
+	}{
+		{
+			name: "unique violation",
+			err:  stateError{"23505", "UNIQUE constraint failed: users.email"},
+			want: DuplicateKeyError{Message: "UNIQUE constraint failed: users.email"},
+		},
+		{
+			name: "foreign key violation",
+			err:  stateError{"23503", "FOREIGN KEY constraint failed"},
+			want: FkConstraintError{Message: "FOREIGN KEY constraint failed"},
+		},
+		{
+			name: "not null violation",
+			err:  stateError{"23502", "NOT NULL constraint failed: users.name"},
+			want: NotNullError{Message: "NOT NULL constraint failed: users.name"},
+		},
+		{
+			name: "other integrity violation",
+			err:  fmt.Errorf("insert: %w", stateError{"23000", "Cannot insert duplicate key row"}),
+			want: DataIntegrityViolationError{SQLState: "23000", Message: "insert: Cannot insert duplicate key row"},
+		},
+		{
+			name: "serialization failure",
+			err:  stateError{"40001", "Transaction was deadlocked"},
+			want: SerializationFailureError{Message: "Transaction was deadlocked"},
+		},
+		{
+			name: "other transaction rollback",
+			err:  stateError{"40003", "statement completion unknown"},
+			want: TransactionRollbackError{SQLState: "40003", Message: "statement completion unknown"},
+		},
+		{
+			name: "read-only transaction",
+			err:  stateError{"25006", "cannot execute INSERT in a read-only transaction"},
+			want: ReadOnlyError{Message: "cannot execute INSERT in a read-only transaction"},
+		},
+		{
+			name: "connection not established",
+			err:  stateError{"08001", "could not connect to server"},
+			want: ConnectionError{Message: "could not connect to server"},
+		},
+		{
+			name: "connection exception",
+			err:  stateError{"08S01", "communication link failure"},
+			want: ConnectionLostError{Message: "communication link failure"},
+		},
+		{
+			name: "syntax error",
+			err:  stateError{"42S02", "Invalid object name 'userz'"},
+			want: BadSqlGrammarError{SQLState: "42S02", Message: "Invalid object name 'userz'"},
+		},
+		{
+			name: "data exception",
+			err:  stateError{"22003", "Arithmetic overflow error"},
+			want: DataError{SQLState: "22003", Message: "Arithmetic overflow error"},
+		},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			t := SqlStateErrorTranslator{}
+			if got := t.TranslateExceptionIfPossible(tt.err); !reflect.DeepEqual(got, tt.want) {
+				t1.Errorf("TranslateExceptionIfPossible() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSqlStateErrorTranslator_Unhandled(t1 *testing.T) {
+	t := SqlStateErrorTranslator{}
+
+	if got := t.TranslateExceptionIfPossible(nil); got != nil {
+		t1.Errorf("TranslateExceptionIfPossible(nil) = %v", got)
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "unknown class", err: stateError{"HY000", "general error"}, want: "unhandled SQLSTATE HY000"},
+		{name: "no SQLSTATE", err: fmt.Errorf("boom"), want: "failed to translate database error"},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			var dbErr merrors.DatabaseError
+			got := t.TranslateExceptionIfPossible(tt.err)
+			if !stderrors.As(got, &dbErr) || dbErr.Message != tt.want {
+				t1.Errorf("TranslateExceptionIfPossible() = %v, want a DatabaseError %q", got, tt.want)
+			}
+		})
+	}
+
+	got := t.TranslateExceptionIfPossible(stateError{"HY000", "general error"})
+	if state := merrors.Attrs(got); len(state) == 0 || state[0].Value.String() != "HY000" {
+		t1.Errorf("Attrs() = %v, want sql_state", state)
+	}
+}
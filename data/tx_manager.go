@@ -0,0 +1,183 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Propagation decides how RunInTx behaves when the context already carries a
+// transaction of the same DB.
+type Propagation int
+
+const (
+	// PropagationRequired joins the current transaction or begins one.
+	PropagationRequired Propagation = iota
+	// PropagationRequiresNew always begins an independent transaction.
+	PropagationRequiresNew
+	// PropagationNested runs inside a savepoint of the current transaction,
+	// or begins one when there is none.
+	PropagationNested
+)
+
+func (p Propagation) String() string {
+	switch p {
+	case PropagationRequired:
+		return "required"
+	case PropagationRequiresNew:
+		return "requires_new"
+	case PropagationNested:
+		return "nested"
+	}
+	return fmt.Sprintf("Propagation(%d)", int(p))
+}
+
+// ErrRollbackOnly is returned by RunInTx when a joined call failed but the
+// outer function still returned nil. The transaction, or the savepoint of a
+// nested call, is rolled back.
+var ErrRollbackOnly = errors.New("data: transaction was marked rollback-only")
+
+type TxOptions struct {
+	Propagation Propagation
+	// Isolation and ReadOnly apply only when a new transaction is begun.
+	Isolation sql.IsolationLevel
+	ReadOnly  bool
+	// Retry runs a new transaction again from the start when it fails with a
+	// retryable error. A joined transaction is never retried on its own.
+	Retry *RetryPolicy
+}
+
+type txKey struct {
+	db *DB
+}
+
+// txState is the scope of a transaction. A nested scope shares the
+// transaction and the savepoint counter, but a failure joined inside it only
+// marks the nested scope rollback-only.
+type txState struct {
+	tx           *Tx
+	savepoints   *int
+	rollbackOnly bool
+}
+
+// TxFromContext returns the transaction of db that RunInTx stored in ctx.
+func (db *DB) TxFromContext(ctx context.Context) (*Tx, bool) {
+	state, ok := ctx.Value(txKey{db}).(*txState)
+	if !ok {
+		return nil, false
+	}
+	return state.tx, true
+}
+
+// TxManager runs functions in transactions carried by the context. Statements
+// issued through the DB with that context join the transaction.
+type TxManager struct {
+	db *DB
+}
+
+func NewTxManager(db *DB) *TxManager {
+	return &TxManager{db: db}
+}
+
+// RunInTx runs fn in a transaction chosen by opts. The transaction is
+// committed when fn returns nil and rolled back when fn returns an error or
+// panics. A nil opts means PropagationRequired with the driver defaults.
+func (m *TxManager) RunInTx(ctx context.Context, opts *TxOptions, fn func(ctx context.Context) error) error {
+	if opts == nil {
+		opts = &TxOptions{}
+	}
+
+	if current, ok := ctx.Value(txKey{m.db}).(*txState); ok {
+		switch opts.Propagation {
+		case PropagationRequired:
+			return m.join(ctx, current, fn)
+		case PropagationNested:
+			return m.nested(ctx, current, fn)
+		}
+	}
+	if opts.Retry != nil {
+		return opts.Retry.Do(ctx, func(ctx context.Context) error {
+			return m.begin(ctx, opts, fn)
+		})
+	}
+	return m.begin(ctx, opts, fn)
+}
+
+func (m *TxManager) begin(ctx context.Context, opts *TxOptions, fn func(ctx context.Context) error) error {
+	tx, err := m.db.BeginTx(ctx, &sql.TxOptions{Isolation: opts.Isolation, ReadOnly: opts.ReadOnly})
+	if err != nil {
+		return err
+	}
+
+	state := &txState{tx: tx, savepoints: new(int)}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{m.db}, state)); err != nil {
+		return rollback(err, tx.Rollback())
+	}
+	if state.rollbackOnly {
+		return rollback(ErrRollbackOnly, tx.Rollback())
+	}
+	return tx.Commit()
+}
+
+// join runs fn in the current transaction. A failure marks the transaction
+// rollback-only, because its work can no longer be committed.
+func (m *TxManager) join(ctx context.Context, state *txState, fn func(ctx context.Context) error) error {
+	defer func() {
+		if p := recover(); p != nil {
+			state.rollbackOnly = true
+			panic(p)
+		}
+	}()
+
+	if err := fn(ctx); err != nil {
+		state.rollbackOnly = true
+		return err
+	}
+	return nil
+}
+
+// nested runs fn inside a savepoint, so a failure undoes only the work of fn.
+func (m *TxManager) nested(ctx context.Context, current *txState, fn func(ctx context.Context) error) error {
+	*current.savepoints++
+	savepoint := fmt.Sprintf("sp_%d", *current.savepoints)
+	state := &txState{tx: current.tx, savepoints: current.savepoints}
+	if _, err := state.tx.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+		return err
+	}
+
+	rollbackToSavepoint := func() error {
+		_, err := state.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepoint)
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			rollbackToSavepoint()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{m.db}, state)); err != nil {
+		return rollback(err, rollbackToSavepoint())
+	}
+	if state.rollbackOnly {
+		return rollback(ErrRollbackOnly, rollbackToSavepoint())
+	}
+	_, err := state.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+savepoint)
+	return err
+}
+
+// rollback returns err, joined with the error of the rollback if it failed.
+func rollback(err, rollbackErr error) error {
+	if rollbackErr != nil {
+		return errors.Join(err, rollbackErr)
+	}
+	return err
+}
@@ -0,0 +1,191 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestTxManager_RunInTx(t *testing.T) {
+	failure := errors.New("failure")
+	tests := []struct {
+		name    string
+		opts    *TxOptions
+		fn      func(db *DB, m *TxManager) func(ctx context.Context) error
+		wantErr error
+		want    string
+	}{
+		{
+			name: "commit",
+			fn: func(db *DB, m *TxManager) func(ctx context.Context) error {
+				return func(ctx context.Context) error {
+					_, err := db.ExecContext(ctx, "INSERT a")
+					return err
+				}
+			},
+			want: "BEGIN; INSERT a; COMMIT",
+		},
+		{
+			name: "rollback on error",
+			fn: func(db *DB, m *TxManager) func(ctx context.Context) error {
+				return func(ctx context.Context) error {
+					db.ExecContext(ctx, "INSERT a")
+					return failure
+				}
+			},
+			wantErr: failure,
+			want:    "BEGIN; INSERT a; ROLLBACK",
+		},
+		{
+			name: "read-only with isolation",
+			opts: &TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true},
+			fn: func(db *DB, m *TxManager) func(ctx context.Context) error {
+				return func(ctx context.Context) error {
+					rows, err := db.QueryContext(ctx, "SELECT a")
+					if err != nil {
+						return err
+					}
+					return rows.Close()
+				}
+			},
+			want: "BEGIN Serializable READ ONLY; SELECT a; COMMIT",
+		},
+		{
+			name: "required joins the current transaction",
+			fn: func(db *DB, m *TxManager) func(ctx context.Context) error {
+				return func(ctx context.Context) error {
+					db.ExecContext(ctx, "INSERT a")
+					return m.RunInTx(ctx, nil, func(ctx context.Context) error {
+						_, err := db.ExecContext(ctx, "INSERT b")
+						return err
+					})
+				}
+			},
+			want: "BEGIN; INSERT a; INSERT b; COMMIT",
+		},
+		{
+			name: "failed joined call marks the transaction rollback-only",
+			fn: func(db *DB, m *TxManager) func(ctx context.Context) error {
+				return func(ctx context.Context) error {
+					m.RunInTx(ctx, nil, func(ctx context.Context) error {
+						return failure
+					})
+					return nil
+				}
+			},
+			wantErr: ErrRollbackOnly,
+			want:    "BEGIN; ROLLBACK",
+		},
+		{
+			name: "requires new begins an independent transaction",
+			fn: func(db *DB, m *TxManager) func(ctx context.Context) error {
+				return func(ctx context.Context) error {
+					db.ExecContext(ctx, "INSERT a")
+					m.RunInTx(ctx, &TxOptions{Propagation: PropagationRequiresNew}, func(ctx context.Context) error {
+						_, err := db.ExecContext(ctx, "INSERT audit")
+						return err
+					})
+					return failure
+				}
+			},
+			wantErr: failure,
+			want:    "BEGIN; INSERT a; BEGIN; INSERT audit; COMMIT; ROLLBACK",
+		},
+		{
+			name: "nested rolls back to a savepoint",
+			fn: func(db *DB, m *TxManager) func(ctx context.Context) error {
+				return func(ctx context.Context) error {
+					db.ExecContext(ctx, "INSERT a")
+					nested := &TxOptions{Propagation: PropagationNested}
+					m.RunInTx(ctx, nested, func(ctx context.Context) error {
+						db.ExecContext(ctx, "INSERT b")
+						return failure
+					})
+					return m.RunInTx(ctx, nested, func(ctx context.Context) error {
+						_, err := db.ExecContext(ctx, "INSERT c")
+						return err
+					})
+				}
+			},
+			want: "BEGIN; INSERT a; SAVEPOINT sp_1; INSERT b; ROLLBACK TO SAVEPOINT sp_1; " +
+				"SAVEPOINT sp_2; INSERT c; RELEASE SAVEPOINT sp_2; COMMIT",
+		},
+		{
+			name: "failed required call inside nested rolls back only the savepoint",
+			fn: func(db *DB, m *TxManager) func(ctx context.Context) error {
+				return func(ctx context.Context) error {
+					db.ExecContext(ctx, "INSERT a")
+					m.RunInTx(ctx, &TxOptions{Propagation: PropagationNested}, func(ctx context.Context) error {
+						db.ExecContext(ctx, "INSERT b")
+						m.RunInTx(ctx, nil, func(ctx context.Context) error {
+							return failure
+						})
+						return nil
+					})
+					_, err := db.ExecContext(ctx, "INSERT c")
+					return err
+				}
+			},
+			want: "BEGIN; INSERT a; SAVEPOINT sp_1; INSERT b; ROLLBACK TO SAVEPOINT sp_1; INSERT c; COMMIT",
+		},
+		{
+			name: "nested without a transaction begins one",
+			opts: &TxOptions{Propagation: PropagationNested},
+			fn: func(db *DB, m *TxManager) func(ctx context.Context) error {
+				return func(ctx context.Context) error {
+					_, err := db.ExecContext(ctx, "INSERT a")
+					return err
+				}
+			},
+			want: "BEGIN; INSERT a; COMMIT",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, server := newFakeDB(t, "fakedb")
+			m := NewTxManager(db)
+
+			err := m.RunInTx(context.Background(), tt.opts, tt.fn(db, m))
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("RunInTx() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := server.statements(); got != tt.want {
+				t.Errorf("statements = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTxManager_RunInTxPanic(t *testing.T) {
+	db, server := newFakeDB(t, "fakedb")
+	m := NewTxManager(db)
+
+	defer func() {
+		if p := recover(); p != "boom" {
+			t.Errorf("recovered %v, want the panic to propagate", p)
+		}
+		if got := server.statements(); got != "BEGIN; INSERT a; ROLLBACK" {
+			t.Errorf("statements = %q", got)
+		}
+	}()
+	m.RunInTx(context.Background(), nil, func(ctx context.Context) error {
+		db.ExecContext(ctx, "INSERT a")
+		panic("boom")
+	})
+}
+
+func TestDB_TxFromContext(t *testing.T) {
+	db, _ := newFakeDB(t, "fakedb")
+	other, _ := newFakeDB(t, "fakedb")
+
+	NewTxManager(db).RunInTx(context.Background(), nil, func(ctx context.Context) error {
+		if _, ok := db.TxFromContext(ctx); !ok {
+			t.Error("TxFromContext() found no transaction inside RunInTx")
+		}
+		if _, ok := other.TxFromContext(ctx); ok {
+			t.Error("TxFromContext() of another DB found the transaction")
+		}
+		return nil
+	})
+}
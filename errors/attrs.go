@@ -0,0 +1,147 @@
+package errors
+
+import (
+	stderrors "errors"
+	"log/slog"
+	"time"
+)
+
+// attrError attaches structured attributes to an error without changing its message.
+type attrError struct {
+	err   error
+	attrs []slog.Attr
+}
+
+// With attaches key/value pairs to err, following the argument conventions
+// of slog: alternating keys and values, or slog.Attr values. The attributes
+// survive further wrapping and are collected by Attrs.
+func With(err error, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+
+	record := slog.NewRecord(time.Time{}, 0, "", 0)
+	record.Add(args...)
+	attrs := make([]slog.Attr, 0, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+	return attrError{err: err, attrs: attrs}
+}
+
+func (e attrError) Error() string {
+	return e.err.Error()
+}
+
+func (e attrError) Unwrap() error {
+	return e.err
+}
+
+// LogValue logs the wrapped error with the attributes of the whole chain.
+func (e attrError) LogValue() slog.Value {
+	var attrs []slog.Attr
+	if valuer, ok := e.err.(slog.LogValuer); ok {
+		if value := valuer.LogValue().Resolve(); value.Kind() == slog.KindGroup {
+			attrs = value.Group()
+		}
+	}
+	if attrs == nil {
+		attrs = []slog.Attr{slog.String("message", e.err.Error())}
+	}
+	return slog.GroupValue(mergeAttrs(attrs, Attrs(e))...)
+}
+
+// Attrs collects the attributes attached with With anywhere in the chain of
+// err, outermost first. A key attached more than once keeps its outermost value.
+func Attrs(err error) []slog.Attr {
+	var attrs []slog.Attr
+	inChain(err, func(err error) bool {
+		if attached, ok := err.(attrError); ok {
+			attrs = mergeAttrs(attrs, attached.attrs)
+		}
+		return false
+	})
+	return attrs
+}
+
+// mergeAttrs appends the attributes of src whose keys are not in dst yet.
+func mergeAttrs(dst, src []slog.Attr) []slog.Attr {
+	for _, attr := range src {
+		present := false
+		for _, existing := range dst {
+			if existing.Key == attr.Key {
+				present = true
+				break
+			}
+		}
+		if !present {
+			dst = append(dst, attr)
+		}
+	}
+	return dst
+}
+
+// causeAttrs describes the cause of an error as log attributes.
+func causeAttrs(cause error) []slog.Attr {
+	if cause == nil {
+		return nil
+	}
+	return append([]slog.Attr{slog.String("cause", cause.Error())}, Attrs(cause)...)
+}
+
+type sqlStater interface {
+	SQLState() string
+}
+
+func (e ConfigurationError) LogValue() slog.Value {
+	return slog.GroupValue(append([]slog.Attr{slog.String("message", e.Message)}, causeAttrs(e.Cause)...)...)
+}
+
+func (e ValidationError) LogValue() slog.Value {
+	attrs := []slog.Attr{slog.String("field", e.Field), slog.String("message", e.Message)}
+	if violations := e.Violations(); len(violations) > 0 {
+		fields := make([]string, 0, len(violations))
+		for _, violation := range violations {
+			fields = append(fields, violation.Field)
+		}
+		attrs = append(attrs, slog.Any("invalid_fields", fields))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// LogValue reports the SQL state of drivers whose errors have a SQLState
+// method. Translators attach it as the "sql_state" attribute otherwise.
+func (e DatabaseError) LogValue() slog.Value {
+	attrs := []slog.Attr{slog.String("operation", e.Operation), slog.String("message", e.Message)}
+	var stater sqlStater
+	if e.Cause != nil && stderrors.As(e.Cause, &stater) {
+		attrs = append(attrs, slog.String("sql_state", stater.SQLState()))
+	}
+	return slog.GroupValue(mergeAttrs(attrs, causeAttrs(e.Cause))...)
+}
+
+func (e HTTPError) LogValue() slog.Value {
+	attrs := []slog.Attr{slog.Int("status", e.StatusCode), slog.String("message", e.Message)}
+	return slog.GroupValue(append(attrs, causeAttrs(e.Cause)...)...)
+}
+
+// LogValue describes any error for structured logs: the message of err, the
+// fields of the outermost error in the chain that implements slog.LogValuer
+// and the attributes of the whole chain.
+//
+//	logger.Error("request failed", "error", errors.LogValue(err))
+func LogValue(err error) slog.Value {
+	if err == nil {
+		return slog.Value{}
+	}
+
+	attrs := []slog.Attr{slog.String("message", err.Error())}
+	var valuer slog.LogValuer
+	if stderrors.As(err, &valuer) {
+		if value := valuer.LogValue().Resolve(); value.Kind() == slog.KindGroup {
+			attrs = mergeAttrs(attrs, value.Group())
+		}
+	}
+	return slog.GroupValue(mergeAttrs(attrs, Attrs(err))...)
+}
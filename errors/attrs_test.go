@@ -0,0 +1,148 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"testing"
+)
+
+type pgError struct {
+	Code string
+}
+
+func (e pgError) Error() string {
+	return "unique_violation"
+}
+
+func (e pgError) SQLState() string {
+	return e.Code
+}
+
+func TestWith(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want []slog.Attr
+	}{
+		{name: "nil", err: With(nil, "user_id", 42), want: nil},
+		{
+			name: "pairs",
+			err:  With(fmt.Errorf("boom"), "user_id", 42, "order", "o-1"),
+			want: []slog.Attr{slog.Int("user_id", 42), slog.String("order", "o-1")},
+		},
+		{
+			name: "attr values",
+			err:  With(fmt.Errorf("boom"), slog.Bool("retry", true)),
+			want: []slog.Attr{slog.Bool("retry", true)},
+		},
+		{
+			name: "survives wrapping, outermost first",
+			err: WrapHTTPError(
+				fmt.Errorf("service: %w", With(WrapDatabaseError(With(fmt.Errorf("boom"), "table", "orders"), "insert", "failed"), "user_id", 42)),
+				500, "failed"),
+			want: []slog.Attr{slog.Int("user_id", 42), slog.String("table", "orders")},
+		},
+		{
+			name: "outermost value wins",
+			err:  With(With(fmt.Errorf("boom"), "user_id", 1), "user_id", 2),
+			want: []slog.Attr{slog.Int("user_id", 2)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.err == nil {
+				if tt.want != nil {
+					t.Fatal("With() returned nil")
+				}
+				return
+			}
+			if got := Attrs(tt.err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Attrs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if err := With(fmt.Errorf("boom"), "user_id", 42); err.Error() != "boom" {
+		t.Errorf("With() changed the message to %q", err.Error())
+	}
+}
+
+func logJSON(t *testing.T, err error) map[string]interface{} {
+	t.Helper()
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Error("request failed", "error", err)
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("invalid log line %q: %v", buf.String(), err)
+	}
+	fields, ok := line["error"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("error is not logged as a group: %s", buf.String())
+	}
+	return fields
+}
+
+func TestLogValue(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want map[string]interface{}
+	}{
+		{
+			name: "database error",
+			err:  WrapDatabaseError(pgError{Code: "23505"}, "insert", "failed to create order"),
+			want: map[string]interface{}{
+				"operation": "insert",
+				"message":   "failed to create order",
+				"sql_state": "23505",
+				"cause":     "unique_violation",
+			},
+		},
+		{
+			name: "database error with request attributes",
+			err: With(WrapDatabaseError(With(fmt.Errorf("Error 1205: Lock wait timeout exceeded"), "sql_state", "HY000"), "update", "failed to update order"),
+				"request_id", "r-1", "user_id", 42),
+			want: map[string]interface{}{
+				"operation":  "update",
+				"message":    "failed to update order",
+				"sql_state":  "HY000",
+				"cause":      "Error 1205: Lock wait timeout exceeded",
+				"request_id": "r-1",
+				"user_id":    float64(42),
+			},
+		},
+		{
+			name: "http error",
+			err:  WrapHTTPError(nil, 404, "order not found"),
+			want: map[string]interface{}{"status": float64(404), "message": "order not found"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := logJSON(t, tt.err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("logged %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogValue_WrappedByFmt(t *testing.T) {
+	err := fmt.Errorf("handler: %w", With(WrapDatabaseError(nil, "query", "failed"), "user_id", 42))
+
+	got := map[string]interface{}{}
+	for _, attr := range LogValue(err).Group() {
+		got[attr.Key] = attr.Value.Any()
+	}
+	want := map[string]interface{}{
+		"message":   "handler: database error during query: failed",
+		"operation": "query",
+		"user_id":   int64(42),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LogValue() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,235 @@
+package errors
+
+import (
+	"embed"
+	stderrors "errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed messages/*.yaml
+var messageFiles embed.FS
+
+const DefaultLocale = "en"
+
+// DefaultCatalog holds the built-in English and Korean messages.
+var DefaultCatalog = newDefaultCatalog()
+
+// Catalog holds messages by locale and error code. Messages may contain
+// placeholders such as {field} that are filled from the error arguments.
+type Catalog struct {
+	mu       sync.RWMutex
+	fallback string
+	messages map[string]map[string]string
+}
+
+func NewCatalog(fallback string) *Catalog {
+	return &Catalog{
+		fallback: fallback,
+		messages: make(map[string]map[string]string),
+	}
+}
+
+func newDefaultCatalog() *Catalog {
+	catalog := NewCatalog(DefaultLocale)
+	if err := catalog.Load(messageFiles, "messages"); err != nil {
+		panic(err)
+	}
+	return catalog
+}
+
+// Load reads the files named messages_<locale>.yaml in dir. Codes already
+// present for a locale are replaced.
+func (c *Catalog) Load(fsys fs.FS, dir string) error {
+	files, err := fs.Glob(fsys, path.Join(dir, "messages_*.yaml"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+		var messages map[string]string
+		if err := yaml.Unmarshal(content, &messages); err != nil {
+			return WrapConfigurationError(err, fmt.Sprintf("invalid message file %s", file))
+		}
+		locale := strings.TrimSuffix(strings.TrimPrefix(path.Base(file), "messages_"), ".yaml")
+		c.Add(locale, messages)
+	}
+	return nil
+}
+
+func (c *Catalog) Add(locale string, messages map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	locale = normalizeLocale(locale)
+	if c.messages[locale] == nil {
+		c.messages[locale] = make(map[string]string, len(messages))
+	}
+	for code, message := range messages {
+		c.messages[locale][code] = message
+	}
+}
+
+// Message returns the message for code in the first locale that has one.
+// A locale such as ko-KR falls back to ko, and the fallback locale is tried last.
+func (c *Catalog) Message(code string, args map[string]interface{}, locales ...string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, locale := range append(append([]string(nil), locales...), c.fallback) {
+		locale = normalizeLocale(locale)
+		for locale != "" {
+			if message, ok := c.messages[locale][code]; ok {
+				return expandMessage(message, args), true
+			}
+			index := strings.LastIndex(locale, "-")
+			if index < 0 {
+				break
+			}
+			locale = locale[:index]
+		}
+	}
+	return "", false
+}
+
+// Localize returns the catalogue message for the code of err, or err.Error()
+// when err has no code or the catalogue has no message for it.
+func (c *Catalog) Localize(err error, locales ...string) string {
+	if err == nil {
+		return ""
+	}
+	if message, ok := c.localize(err, locales); ok {
+		return message
+	}
+	return err.Error()
+}
+
+func (c *Catalog) localize(err error, locales []string) (string, bool) {
+	code := CodeOf(err)
+	if code == "" {
+		return "", false
+	}
+	var args map[string]interface{}
+	if withArgs, ok := err.(interface{ MessageArgs() map[string]interface{} }); ok {
+		args = withArgs.MessageArgs()
+	}
+	return c.Message(code, args, locales...)
+}
+
+// LocalizeProblem replaces the title of problem by the message for the code
+// of err, localizes the messages of invalid fields and adds the codes. The
+// title of a validation error is the message for CodeValidation, because the
+// message for the code of a single violation may need arguments.
+func (c *Catalog) LocalizeProblem(problem Problem, err error, locales ...string) Problem {
+	code := CodeOf(err)
+	if code == "" {
+		return problem
+	}
+
+	var validationErr ValidationError
+	var validationErrPtr *ValidationError
+	isValidation := true
+	switch {
+	case stderrors.As(err, &validationErr):
+	case stderrors.As(err, &validationErrPtr):
+		validationErr = *validationErrPtr
+	default:
+		isValidation = false
+	}
+
+	if isValidation {
+		code = CodeValidation
+	}
+	if title, ok := c.Message(code, nil, locales...); ok {
+		problem.Title = title
+	}
+	if !isValidation {
+		return problem
+	}
+
+	violations := validationErr.Violations()
+	if len(violations) == 0 {
+		violations = []ValidationError{validationErr}
+	}
+	invalidFields := make([]map[string]string, 0, len(violations))
+	for _, violation := range violations {
+		message := violation.Message
+		if violation.ErrorCode != "" {
+			if localized, ok := c.localize(violation, locales); ok {
+				message = localized
+			}
+		}
+		invalidFields = append(invalidFields, map[string]string{
+			"field":   violation.Field,
+			"message": message,
+			"code":    violation.Code(),
+		})
+	}
+
+	extensions := make(map[string]interface{}, len(problem.Extensions)+1)
+	for name, value := range problem.Extensions {
+		extensions[name] = value
+	}
+	extensions["invalid-fields"] = invalidFields
+	problem.Extensions = extensions
+	return problem
+}
+
+func expandMessage(message string, args map[string]interface{}) string {
+	if len(args) == 0 {
+		return message
+	}
+	replacements := make([]string, 0, len(args)*2)
+	for name, value := range args {
+		replacements = append(replacements, "{"+name+"}", fmt.Sprint(value))
+	}
+	return strings.NewReplacer(replacements...).Replace(message)
+}
+
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// ParseAcceptLanguage returns the languages of an Accept-Language header,
+// most preferred first.
+func ParseAcceptLanguage(header string) []string {
+	type language struct {
+		tag     string
+		quality float64
+	}
+
+	var languages []language
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				quality = parsed
+			}
+		}
+		if quality > 0 {
+			languages = append(languages, language{tag: tag, quality: quality})
+		}
+	}
+
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].quality > languages[j].quality
+	})
+	tags := make([]string, 0, len(languages))
+	for _, language := range languages {
+		tags = append(tags, language.tag)
+	}
+	return tags
+}
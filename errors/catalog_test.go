@@ -0,0 +1,162 @@
+package errors
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCatalog_Message(t *testing.T) {
+	catalog := NewCatalog("en")
+	err := catalog.Load(fstest.MapFS{
+		"i18n/messages_en.yaml": {Data: []byte("VAL-MIN: must be at least {min}\nAPP-001: Order {order} not found\n")},
+		"i18n/messages_ko.yaml": {Data: []byte("VAL-MIN: \"{min} 이상이어야 합니다\"\n")},
+		"i18n/README.md":        {Data: []byte("not a message file")},
+	}, "i18n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		code    string
+		args    map[string]interface{}
+		locales []string
+		want    string
+		wantOk  bool
+	}{
+		{name: "exact locale", code: "VAL-MIN", args: map[string]interface{}{"min": 1}, locales: []string{"ko"}, want: "1 이상이어야 합니다", wantOk: true},
+		{name: "region falls back to language", code: "VAL-MIN", args: map[string]interface{}{"min": 1}, locales: []string{"ko-KR"}, want: "1 이상이어야 합니다", wantOk: true},
+		{name: "missing code falls back", code: "APP-001", args: map[string]interface{}{"order": "o-1"}, locales: []string{"ko"}, want: "Order o-1 not found", wantOk: true},
+		{name: "unknown locale falls back", code: "VAL-MIN", args: map[string]interface{}{"min": 2}, locales: []string{"fr"}, want: "must be at least 2", wantOk: true},
+		{name: "unknown code", code: "APP-404", want: "", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := catalog.Message(tt.code, tt.args, tt.locales...)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("Message() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestDefaultCatalog_Localize(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		locale string
+		want   string
+	}{
+		{name: "validation en", err: NewValidationError("Port", CodeMax, map[string]interface{}{"max": 65535}), locale: "en", want: "must be at most 65535"},
+		{name: "validation ko", err: NewValidationError("Name", CodeRequired, nil), locale: "ko", want: "필수 항목입니다"},
+		{name: "rate limit ko", err: WrapHTTPError(nil, http.StatusTooManyRequests, "slow down"), locale: "ko", want: "요청이 너무 많습니다"},
+		{name: "configuration ko", err: WrapConfigurationError(nil, "missing datasource"), locale: "ko", want: "설정 오류"},
+		{name: "without code", err: errorString("boom"), locale: "ko", want: "boom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DefaultCatalog.Localize(tt.err, tt.locale); got != tt.want {
+				t.Errorf("Localize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+type errorString string
+
+func (e errorString) Error() string { return string(e) }
+
+func TestNewValidationError(t *testing.T) {
+	err := NewValidationError("Port", CodeMin, map[string]interface{}{"min": 1})
+	if got, want := err.Error(), "validation error for field 'Port': must be at least 1"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if got := CodeOf(err); got != CodeMin {
+		t.Errorf("CodeOf() = %q, want %q", got, CodeMin)
+	}
+}
+
+func TestValidationError_Comparable(t *testing.T) {
+	required := ValidationError{Field: "Name", Message: "field is required", ErrorCode: CodeRequired}
+	if got := *NewValidationError("Name", CodeRequired, nil); got != required {
+		t.Errorf("NewValidationError() = %+v, want %+v", got, required)
+	}
+
+	seen := map[error]bool{required: true}
+	aggregate := NewAggregateValidationError("configuration", "validation failed", []ValidationError{required})
+	seen[*aggregate] = true
+	if len(seen) != 2 || len(aggregate.Violations()) != 1 {
+		t.Errorf("seen = %v, want both errors as map keys", seen)
+	}
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{header: "", want: []string{}},
+		{header: "ko", want: []string{"ko"}},
+		{header: "en;q=0.5, ko-KR, ko;q=0.9, *;q=0.1", want: []string{"ko-KR", "ko", "en"}},
+		{header: "fr;q=0, en", want: []string{"en"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := ParseAcceptLanguage(tt.header); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseAcceptLanguage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorMapper_WriteProblemLocalized(t *testing.T) {
+	validation := NewAggregateValidationError("configuration", "validation failed", []ValidationError{
+		*NewValidationError("Name", CodeRequired, nil),
+		*NewValidationError("Port", CodeMin, map[string]interface{}{"min": 1}),
+	})
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/servers", nil)
+	request.Header.Set("Accept-Language", "ko-KR,ko;q=0.9,en;q=0.8")
+	NewErrorMapper().WriteProblem(recorder, request, validation)
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if body["title"] != "입력값 검증 실패" || body["code"] != CodeValidation {
+		t.Errorf("title = %v, code = %v", body["title"], body["code"])
+	}
+	want := []interface{}{
+		map[string]interface{}{"field": "Name", "message": "필수 항목입니다", "code": "VAL-REQUIRED"},
+		map[string]interface{}{"field": "Port", "message": "1 이상이어야 합니다", "code": "VAL-MIN"},
+	}
+	if !reflect.DeepEqual(body["invalid-fields"], want) {
+		t.Errorf("invalid-fields = %v, want %v", body["invalid-fields"], want)
+	}
+}
+
+func TestErrorMapper_WriteProblemSingleViolation(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/servers", nil)
+	request.Header.Set("Accept-Language", "en")
+	NewErrorMapper().WriteProblem(recorder, request, NewValidationError("port", CodeMin, map[string]interface{}{"min": 1}))
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if body["title"] != "Validation Failed" {
+		t.Errorf("title = %v, want %q", body["title"], "Validation Failed")
+	}
+	want := []interface{}{
+		map[string]interface{}{"field": "port", "message": "must be at least 1", "code": "VAL-MIN"},
+	}
+	if !reflect.DeepEqual(body["invalid-fields"], want) {
+		t.Errorf("invalid-fields = %v, want %v", body["invalid-fields"], want)
+	}
+}
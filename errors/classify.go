@@ -0,0 +1,146 @@
+package errors
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	stderrors "errors"
+	"net/http"
+)
+
+// Classification is a set of properties of an error that callers act on
+// without knowing its concrete type.
+type Classification uint
+
+const (
+	// Transient errors may go away without any change to the request.
+	Transient Classification = 1 << iota
+	// Retryable errors are safe to retry as they are.
+	Retryable
+	NotFound
+	Conflict
+	// ClientError means the request itself is wrong and should not be repeated unchanged.
+	ClientError
+	Timeout
+)
+
+// Has reports whether every property of flags is set.
+func (c Classification) Has(flags Classification) bool {
+	return c&flags == flags
+}
+
+// Classifier is implemented by errors that know their own classification.
+type Classifier interface {
+	Classify() Classification
+}
+
+type timeoutError interface {
+	Timeout() bool
+}
+
+// Classify combines the classification of every error in the chain of err,
+// including errors joined with errors.Join.
+func Classify(err error) Classification {
+	if err == nil {
+		return 0
+	}
+
+	var classification Classification
+	if classifier, ok := err.(Classifier); ok {
+		classification |= classifier.Classify()
+	}
+	// context.DeadlineExceeded and net.Error timeouts report themselves.
+	if timeout, ok := err.(timeoutError); ok && timeout.Timeout() {
+		classification |= Timeout | Transient
+	}
+
+	switch wrapped := err.(type) {
+	case interface{ Unwrap() error }:
+		classification |= Classify(wrapped.Unwrap())
+	case interface{ Unwrap() []error }:
+		for _, inner := range wrapped.Unwrap() {
+			classification |= Classify(inner)
+		}
+	}
+	return classification
+}
+
+func IsTransient(err error) bool {
+	return Classify(err).Has(Transient)
+}
+
+func IsRetryable(err error) bool {
+	return Classify(err).Has(Retryable)
+}
+
+func IsNotFound(err error) bool {
+	return Classify(err).Has(NotFound)
+}
+
+func IsConflict(err error) bool {
+	return Classify(err).Has(Conflict)
+}
+
+func IsClientError(err error) bool {
+	return Classify(err).Has(ClientError)
+}
+
+func IsTimeout(err error) bool {
+	return Classify(err).Has(Timeout)
+}
+
+// StatusForClassification maps a classification to the HTTP status that
+// describes it best, or 500 when none applies.
+func StatusForClassification(classification Classification) int {
+	switch {
+	case classification.Has(NotFound):
+		return http.StatusNotFound
+	case classification.Has(Conflict):
+		return http.StatusConflict
+	case classification.Has(ClientError):
+		return http.StatusBadRequest
+	case classification.Has(Timeout):
+		return http.StatusGatewayTimeout
+	case classification.Has(Transient):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+func (e ValidationError) Classify() Classification {
+	return ClientError
+}
+
+// Classify recognizes the sentinel errors of database/sql and its drivers.
+func (e DatabaseError) Classify() Classification {
+	switch {
+	case stderrors.Is(e.Cause, sql.ErrNoRows):
+		return NotFound
+	case stderrors.Is(e.Cause, driver.ErrBadConn):
+		// The driver guarantees the statement was not sent.
+		return Transient | Retryable
+	case stderrors.Is(e.Cause, sql.ErrConnDone):
+		return Transient
+	}
+	return 0
+}
+
+func (e HTTPError) Classify() Classification {
+	switch e.StatusCode {
+	case http.StatusNotFound, http.StatusGone:
+		return NotFound | ClientError
+	case http.StatusConflict:
+		return Conflict | ClientError
+	case http.StatusRequestTimeout:
+		return Timeout | Transient | Retryable | ClientError
+	case http.StatusTooManyRequests:
+		return Transient | Retryable | ClientError
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return Transient | Retryable
+	case http.StatusGatewayTimeout:
+		return Timeout | Transient
+	}
+	if e.StatusCode >= 400 && e.StatusCode < 500 {
+		return ClientError
+	}
+	return 0
+}
@@ -0,0 +1,104 @@
+package errors
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type lockTimeoutError struct{}
+
+func (lockTimeoutError) Error() string { return "lock wait timeout" }
+
+func (lockTimeoutError) Classify() Classification { return Transient | Retryable }
+
+type uniqueViolationError struct{}
+
+func (uniqueViolationError) Error() string { return "duplicate entry" }
+
+func (uniqueViolationError) Classify() Classification { return Conflict }
+
+func TestClassify(t *testing.T) {
+	expired, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+	<-expired.Done()
+
+	tests := []struct {
+		name string
+		err  error
+		want Classification
+	}{
+		{name: "nil", err: nil, want: 0},
+		{name: "plain error", err: fmt.Errorf("boom"), want: 0},
+		{name: "deadline", err: expired.Err(), want: Timeout | Transient},
+		{name: "wrapped deadline", err: WrapDatabaseError(fmt.Errorf("query: %w", context.DeadlineExceeded), "query", "timed out"), want: Timeout | Transient},
+		{name: "canceled", err: context.Canceled, want: 0},
+		{name: "no rows", err: WrapDatabaseError(sql.ErrNoRows, "query", "user not found"), want: NotFound},
+		{name: "bad conn", err: WrapDatabaseError(driver.ErrBadConn, "exec", "connection lost"), want: Transient | Retryable},
+		{name: "validation", err: &ValidationError{Field: "name", Message: "field is required"}, want: ClientError},
+		{name: "http 404", err: WrapHTTPError(nil, http.StatusNotFound, "missing"), want: NotFound | ClientError},
+		{name: "http 429", err: WrapHTTPError(nil, http.StatusTooManyRequests, "slow down"), want: Transient | Retryable | ClientError},
+		{name: "http 503", err: WrapHTTPError(nil, http.StatusServiceUnavailable, "down"), want: Transient | Retryable},
+		{name: "http 500", err: WrapHTTPError(nil, http.StatusInternalServerError, "failed"), want: 0},
+		{name: "custom classifier", err: WithStackTrace(lockTimeoutError{}, "update failed"), want: Transient | Retryable},
+		{name: "joined", err: stderrors.Join(fmt.Errorf("boom"), lockTimeoutError{}), want: Transient | Retryable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.want {
+				t.Errorf("Classify() = %b, want %b", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPredicates(t *testing.T) {
+	err := fmt.Errorf("load order: %w", WrapHTTPError(nil, http.StatusRequestTimeout, "slow client"))
+
+	tests := []struct {
+		name      string
+		predicate func(error) bool
+		want      bool
+	}{
+		{name: "IsTransient", predicate: IsTransient, want: true},
+		{name: "IsRetryable", predicate: IsRetryable, want: true},
+		{name: "IsNotFound", predicate: IsNotFound, want: false},
+		{name: "IsConflict", predicate: IsConflict, want: false},
+		{name: "IsClientError", predicate: IsClientError, want: true},
+		{name: "IsTimeout", predicate: IsTimeout, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.predicate(err); got != tt.want {
+				t.Errorf("%s() = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProblemOf_Classification(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "database not found", err: WrapDatabaseError(sql.ErrNoRows, "query", "user not found"), want: http.StatusNotFound},
+		{name: "database conflict", err: WrapDatabaseError(uniqueViolationError{}, "insert", "user exists"), want: http.StatusConflict},
+		{name: "database attributed conflict", err: WrapDatabaseError(With(uniqueViolationError{}, "table", "users"), "insert", "user exists"), want: http.StatusConflict},
+		{name: "database transient", err: WrapDatabaseError(lockTimeoutError{}, "update", "lock wait"), want: http.StatusServiceUnavailable},
+		{name: "classified error", err: lockTimeoutError{}, want: http.StatusServiceUnavailable},
+		{name: "deadline", err: context.DeadlineExceeded, want: http.StatusGatewayTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ProblemOf(tt.err).Status; got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
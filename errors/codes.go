@@ -0,0 +1,105 @@
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"net/http"
+)
+
+// Stable error codes. Messages for them are kept in the Catalog.
+const (
+	CodeConfiguration = "CFG-001"
+
+	CodeValidation = "VAL-001"
+	CodeRequired   = "VAL-REQUIRED"
+	CodeMin        = "VAL-MIN"
+	CodeMax        = "VAL-MAX"
+	CodeMinLength  = "VAL-MINLEN"
+	CodeMaxLength  = "VAL-MAXLEN"
+	CodePattern    = "VAL-PATTERN"
+
+	CodeDatabase        = "DB-001"
+	CodeDuplicateKey    = "DB-DUPKEY"
+	CodeForeignKey      = "DB-FK"
+	CodeConnection      = "DB-CONN"
+	CodeSQLSyntax       = "DB-SYNTAX"
+	CodeNotNull         = "DB-NOTNULL"
+	CodeDeadlock        = "DB-DEADLOCK"
+	CodeSerialization   = "DB-SERIALIZE"
+	CodeQueryTimeout    = "DB-TIMEOUT"
+	CodeDataIntegrity   = "DB-INTEGRITY"
+	CodeRollback        = "DB-ROLLBACK"
+	CodeInvalidData     = "DB-DATA"
+	CodeDataTooLong     = "DB-TOOLONG"
+	CodeLockTimeout     = "DB-LOCKTIMEOUT"
+	CodeOptimisticLock  = "DB-OPTLOCK"
+	CodeResultSize      = "DB-RESULTSIZE"
+	CodeEmptyResult     = "DB-EMPTY"
+	CodeReadOnly        = "DB-READONLY"
+	CodeHTTPRateLimited = "HTTP-RATE"
+)
+
+// Coder is implemented by errors with a stable, machine-readable code.
+type Coder interface {
+	Code() string
+}
+
+// CodeOf returns the code of the outermost Coder in the chain of err, or "".
+func CodeOf(err error) string {
+	var coder Coder
+	if stderrors.As(err, &coder) {
+		return coder.Code()
+	}
+	return ""
+}
+
+// NewValidationError builds a ValidationError whose message is the default
+// catalogue message for code, filled with args such as {"min": 1}.
+func NewValidationError(field, code string, args map[string]interface{}) *ValidationError {
+	message, ok := DefaultCatalog.Message(code, args)
+	if !ok {
+		message = code
+	}
+	validationErr := &ValidationError{
+		Field:     field,
+		Message:   message,
+		ErrorCode: code,
+	}
+	if len(args) > 0 {
+		validationErr.details = &validationDetails{args: args}
+	}
+	return validationErr
+}
+
+func (e ConfigurationError) Code() string {
+	return CodeConfiguration
+}
+
+func (e ValidationError) Code() string {
+	if e.ErrorCode != "" {
+		return e.ErrorCode
+	}
+	return CodeValidation
+}
+
+// MessageArgs returns the arguments of the catalogue message together with the field.
+func (e ValidationError) MessageArgs() map[string]interface{} {
+	args := map[string]interface{}{"field": e.Field}
+	if e.details != nil {
+		for name, value := range e.details.args {
+			args[name] = value
+		}
+	}
+	return args
+}
+
+func (e DatabaseError) Code() string {
+	return CodeDatabase
+}
+
+func (e HTTPError) Code() string {
+	if e.StatusCode == http.StatusTooManyRequests {
+		return CodeHTTPRateLimited
+	}
+	return fmt.Sprintf("HTTP-%d", e.StatusCode)
+}
@@ -2,7 +2,6 @@ package errors
 
 import (
 	"fmt"
-	"runtime"
 )
 
 // Error types
@@ -25,12 +24,40 @@ func (e ConfigurationError) Unwrap() error {
 type ValidationError struct {
 	Field   string
 	Message string
+	// ErrorCode selects the catalogue message, e.g. VAL-MIN.
+	ErrorCode string
+
+	// details is a pointer so that ValidationError stays comparable.
+	details *validationDetails
+}
+
+type validationDetails struct {
+	args       map[string]interface{}
+	violations []ValidationError
+}
+
+// NewAggregateValidationError builds a ValidationError that lists the
+// individual failures of a validation.
+func NewAggregateValidationError(field, message string, violations []ValidationError) *ValidationError {
+	validationErr := &ValidationError{Field: field, Message: message}
+	if len(violations) > 0 {
+		validationErr.details = &validationDetails{violations: violations}
+	}
+	return validationErr
 }
 
 func (e ValidationError) Error() string {
 	return fmt.Sprintf("validation error for field '%s': %s", e.Field, e.Message)
 }
 
+// Violations returns the individual failures of an aggregated validation.
+func (e ValidationError) Violations() []ValidationError {
+	if e.details == nil {
+		return nil
+	}
+	return e.details.violations
+}
+
 type DatabaseError struct {
 	Operation string
 	Message   string
@@ -88,32 +115,3 @@ func WrapHTTPError(err error, statusCode int, message string) error {
 		Cause:      err,
 	}
 }
-
-// Stack trace utilities
-type StackTraceError struct {
-	Message string
-	Cause   error
-	Stack   []uintptr
-}
-
-func (e StackTraceError) Error() string {
-	if e.Cause != nil {
-		return fmt.Sprintf("%s (caused by: %v)", e.Message, e.Cause)
-	}
-	return e.Message
-}
-
-func (e StackTraceError) Unwrap() error {
-	return e.Cause
-}
-
-func WithStackTrace(err error, message string) error {
-	var stack [32]uintptr
-	n := runtime.Callers(3, stack[:])
-
-	return StackTraceError{
-		Message: message,
-		Cause:   err,
-		Stack:   stack[:n],
-	}
-}
@@ -0,0 +1,214 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"reflect"
+	"sync"
+
+	"github.com/zbum/mantyboot/http/mime"
+)
+
+type errorMapping struct {
+	matches func(error) bool
+	problem func(error) Problem
+	// localize is false for problems written by the application.
+	localize bool
+}
+
+// ErrorMapper turns errors into problem details responses. Mappings are
+// tried in registration order and the first match wins; errors without a
+// match are described by ProblemOf.
+type ErrorMapper struct {
+	mu       sync.RWMutex
+	mappings []errorMapping
+	catalog  *Catalog
+	reporter Reporter
+}
+
+// DefaultErrorMapper is used by WriteProblem and the recovery middleware.
+var DefaultErrorMapper = NewErrorMapper()
+
+func NewErrorMapper() *ErrorMapper {
+	return &ErrorMapper{catalog: DefaultCatalog}
+}
+
+// SetCatalog replaces the catalogue used to localize responses. A nil
+// catalogue disables localization.
+func (m *ErrorMapper) SetCatalog(catalog *Catalog) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.catalog = catalog
+}
+
+// SetReporter sends the errors of server error responses to reporter.
+func (m *ErrorMapper) SetReporter(reporter Reporter) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reporter = reporter
+}
+
+// Report sends err to the reporter, if one is set.
+func (m *ErrorMapper) Report(r *http.Request, err error) {
+	m.mu.RLock()
+	reporter := m.reporter
+	m.mu.RUnlock()
+	if reporter == nil || err == nil {
+		return
+	}
+
+	ctx := context.Background()
+	if r != nil {
+		ctx = r.Context()
+	}
+	_ = reporter.Report(ctx, NewEvent(err).WithRequest(r))
+}
+
+// Register answers errors matching predicate with status.
+func (m *ErrorMapper) Register(predicate func(error) bool, status int) {
+	m.register(errorMapping{matches: predicate, localize: true, problem: func(err error) Problem {
+		return withStatus(ProblemOf(err), status)
+	}})
+}
+
+// RegisterFunc describes errors matching predicate with problem, which is
+// written as is.
+func (m *ErrorMapper) RegisterFunc(predicate func(error) bool, problem func(error) Problem) {
+	m.register(errorMapping{matches: predicate, problem: problem})
+}
+
+func (m *ErrorMapper) register(mapping errorMapping) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mappings = append(m.mappings, mapping)
+}
+
+// RegisterIs answers errors whose chain contains target with status.
+func (m *ErrorMapper) RegisterIs(target error, status int) {
+	m.Register(func(err error) bool {
+		return stderrors.Is(err, target)
+	}, status)
+}
+
+// RegisterType answers errors whose chain contains an E, or a pointer to
+// one, with status:
+//
+//	errors.RegisterType[mysql.DuplicateKeyError](mapper, http.StatusConflict)
+func RegisterType[E error](m *ErrorMapper, status int) {
+	m.Register(IsType[E], status)
+}
+
+// IsType reports whether the chain of err contains an E or a pointer to one.
+func IsType[E error](err error) bool {
+	var target E
+	if stderrors.As(err, &target) {
+		return true
+	}
+	pointerType := reflect.PointerTo(reflect.TypeOf(&target).Elem())
+	return inChain(err, func(err error) bool {
+		return reflect.TypeOf(err) == pointerType
+	})
+}
+
+func inChain(err error, match func(error) bool) bool {
+	if err == nil {
+		return false
+	}
+	if match(err) {
+		return true
+	}
+	switch wrapped := err.(type) {
+	case interface{ Unwrap() error }:
+		return inChain(wrapped.Unwrap(), match)
+	case interface{ Unwrap() []error }:
+		for _, inner := range wrapped.Unwrap() {
+			if inChain(inner, match) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Problem describes err using the first matching mapping.
+func (m *ErrorMapper) Problem(err error) Problem {
+	problem, _ := m.problem(err)
+	return problem
+}
+
+// LocalizedProblem describes err like Problem, in the first of the locales
+// the catalogue has messages for.
+func (m *ErrorMapper) LocalizedProblem(err error, locales ...string) Problem {
+	problem, localize := m.problem(err)
+
+	m.mu.RLock()
+	catalog := m.catalog
+	m.mu.RUnlock()
+	if !localize || catalog == nil {
+		return problem
+	}
+	return catalog.LocalizeProblem(problem, err, locales...)
+}
+
+func (m *ErrorMapper) problem(err error) (Problem, bool) {
+	m.mu.RLock()
+	mappings := m.mappings
+	m.mu.RUnlock()
+
+	for _, mapping := range mappings {
+		if mapping.matches(err) {
+			return mapping.problem(err), mapping.localize
+		}
+	}
+	return ProblemOf(err), true
+}
+
+func (m *ErrorMapper) Status(err error) int {
+	return m.Problem(err).Status
+}
+
+// WriteProblem writes err as an application/problem+json response whose
+// instance is the request path, localized for the Accept-Language header.
+// Errors answered with a 5xx status are reported.
+func (m *ErrorMapper) WriteProblem(w http.ResponseWriter, r *http.Request, err error) {
+	var locales []string
+	if r != nil {
+		locales = ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+	}
+	problem := m.LocalizedProblem(err, locales...)
+	if r != nil && r.URL != nil {
+		problem.Instance = r.URL.Path
+	}
+
+	if problem.Status >= http.StatusInternalServerError {
+		m.Report(r, err)
+	}
+
+	w.Header().Set(mime.HeadContentType, mime.ContentTypeApplicationProblemJson)
+	w.WriteHeader(problem.Status)
+	_ = json.NewEncoder(w).Encode(problem)
+}
+
+// HandlerFunc adapts a handler that returns its error instead of writing it.
+func (m *ErrorMapper) HandlerFunc(handler func(http.ResponseWriter, *http.Request) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := handler(w, r); err != nil {
+			m.WriteProblem(w, r, err)
+		}
+	}
+}
+
+func withStatus(problem Problem, status int) Problem {
+	problem.Status = status
+	if problem.Type == "" || problem.Type == "about:blank" {
+		problem.Title = http.StatusText(status)
+	}
+	return problem
+}
+
+// WriteProblem writes err with DefaultErrorMapper.
+func WriteProblem(w http.ResponseWriter, r *http.Request, err error) {
+	DefaultErrorMapper.WriteProblem(w, r, err)
+}
@@ -0,0 +1,110 @@
+package errors
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type duplicateKeyError struct {
+	Table string
+}
+
+func (e duplicateKeyError) Error() string {
+	return "duplicate key on " + e.Table
+}
+
+type quotaError struct{}
+
+func (e *quotaError) Error() string {
+	return "quota exceeded"
+}
+
+func TestErrorMapper_Status(t *testing.T) {
+	mapper := NewErrorMapper()
+	RegisterType[duplicateKeyError](mapper, http.StatusConflict)
+	RegisterType[ValidationError](mapper, http.StatusUnprocessableEntity)
+	RegisterType[*quotaError](mapper, http.StatusPaymentRequired)
+	mapper.RegisterIs(sql.ErrNoRows, http.StatusNotFound)
+	mapper.Register(func(err error) bool {
+		return strings.Contains(err.Error(), "maintenance")
+	}, http.StatusServiceUnavailable)
+	mapper.Register(func(err error) bool { return true }, http.StatusTeapot)
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "type", err: fmt.Errorf("insert: %w", duplicateKeyError{Table: "users"}), want: http.StatusConflict},
+		{name: "pointer to type", err: &ValidationError{Field: "name", Message: "field is required"}, want: http.StatusUnprocessableEntity},
+		{name: "pointer type", err: fmt.Errorf("charge: %w", &quotaError{}), want: http.StatusPaymentRequired},
+		{name: "sentinel", err: WrapDatabaseError(sql.ErrNoRows, "query", "no user"), want: http.StatusNotFound},
+		{name: "predicate", err: fmt.Errorf("down for maintenance"), want: http.StatusServiceUnavailable},
+		{name: "first match wins", err: fmt.Errorf("boom"), want: http.StatusTeapot},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mapper.Status(tt.err); got != tt.want {
+				t.Errorf("Status() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorMapper_Problem(t *testing.T) {
+	mapper := NewErrorMapper()
+	RegisterType[duplicateKeyError](mapper, http.StatusConflict)
+	mapper.RegisterFunc(func(err error) bool { return IsType[*quotaError](err) }, func(err error) Problem {
+		return Problem{Type: "https://example.com/problems/quota", Title: "Quota Exceeded", Status: http.StatusPaymentRequired}
+	})
+
+	if got := mapper.Problem(duplicateKeyError{Table: "users"}); got.Title != "Conflict" || got.Status != http.StatusConflict {
+		t.Errorf("Problem() = %+v, want a 409 Conflict", got)
+	}
+	if got := mapper.Problem(&quotaError{}); got.Type != "https://example.com/problems/quota" {
+		t.Errorf("Problem() = %+v, want the registered problem", got)
+	}
+	// Unmapped errors fall back to ProblemOf.
+	if got := mapper.Problem(ValidationError{Field: "name", Message: "field is required"}); got.Status != http.StatusBadRequest || got.Title != "Validation Failed" {
+		t.Errorf("Problem() = %+v, want the validation problem", got)
+	}
+}
+
+func TestErrorMapper_HandlerFunc(t *testing.T) {
+	mapper := NewErrorMapper()
+	RegisterType[duplicateKeyError](mapper, http.StatusConflict)
+
+	handler := mapper.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		if r.URL.Query().Get("fail") != "" {
+			return duplicateKeyError{Table: "users"}
+		}
+		w.WriteHeader(http.StatusCreated)
+		return nil
+	})
+
+	tests := []struct {
+		name        string
+		target      string
+		wantStatus  int
+		contentType string
+	}{
+		{name: "success", target: "/users", wantStatus: http.StatusCreated},
+		{name: "error", target: "/users?fail=1", wantStatus: http.StatusConflict, contentType: "application/problem+json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			handler(recorder, httptest.NewRequest(http.MethodPost, tt.target, nil))
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if got := recorder.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("content type = %q, want %q", got, tt.contentType)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package errors
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+)
+
+// ProblemTypeBase prefixes the type URI of the problems described by this package.
+var ProblemTypeBase = "urn:mantyboot:problem:"
+
+// Problem is an RFC 7807 problem details object. Extensions are written as
+// members next to the standard ones.
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]interface{}
+}
+
+// ProblemDetailer is implemented by errors that describe themselves as a Problem.
+type ProblemDetailer interface {
+	ProblemDetails() Problem
+}
+
+// StatusCoder is implemented by errors that carry an HTTP status.
+type StatusCoder interface {
+	HTTPStatus() int
+}
+
+func (p Problem) MarshalJSON() ([]byte, error) {
+	members := make(map[string]interface{}, len(p.Extensions)+5)
+	for name, value := range p.Extensions {
+		members[name] = value
+	}
+
+	problemType := p.Type
+	if problemType == "" {
+		problemType = "about:blank"
+	}
+	members["type"] = problemType
+	if p.Title != "" {
+		members["title"] = p.Title
+	}
+	if p.Status != 0 {
+		members["status"] = p.Status
+	}
+	if p.Detail != "" {
+		members["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		members["instance"] = p.Instance
+	}
+	return json.Marshal(members)
+}
+
+func (e ConfigurationError) HTTPStatus() int {
+	return http.StatusInternalServerError
+}
+
+func (e ConfigurationError) ProblemDetails() Problem {
+	return Problem{
+		Type:   ProblemTypeBase + "configuration",
+		Title:  "Configuration Error",
+		Status: e.HTTPStatus(),
+		Detail: e.Message,
+	}
+}
+
+func (e ValidationError) HTTPStatus() int {
+	return http.StatusBadRequest
+}
+
+func (e ValidationError) ProblemDetails() Problem {
+	violations := e.Violations()
+	if len(violations) == 0 {
+		violations = []ValidationError{e}
+	}
+
+	invalidFields := make([]map[string]string, 0, len(violations))
+	for _, violation := range violations {
+		invalidFields = append(invalidFields, map[string]string{
+			"field":   violation.Field,
+			"message": violation.Message,
+			"code":    violation.Code(),
+		})
+	}
+
+	return Problem{
+		Type:       ProblemTypeBase + "validation",
+		Title:      "Validation Failed",
+		Status:     e.HTTPStatus(),
+		Detail:     e.Error(),
+		Extensions: map[string]interface{}{"invalid-fields": invalidFields},
+	}
+}
+
+// HTTPStatus follows the classification of the cause as well as the
+// database/sql sentinels, so a wrapped duplicate key answers 409.
+func (e DatabaseError) HTTPStatus() int {
+	return StatusForClassification(Classify(e.Cause) | e.Classify())
+}
+
+// ProblemDetails leaves out the cause, which may contain SQL or driver details.
+func (e DatabaseError) ProblemDetails() Problem {
+	return Problem{
+		Type:       ProblemTypeBase + "database",
+		Title:      "Database Error",
+		Status:     e.HTTPStatus(),
+		Detail:     e.Message,
+		Extensions: map[string]interface{}{"operation": e.Operation},
+	}
+}
+
+func (e HTTPError) HTTPStatus() int {
+	return e.StatusCode
+}
+
+func (e HTTPError) ProblemDetails() Problem {
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(e.StatusCode),
+		Status: e.StatusCode,
+		Detail: e.Message,
+	}
+}
+
+// ProblemOf describes err as a Problem. The details come from the outermost
+// ProblemDetailer in the chain and the status from the outermost StatusCoder,
+// so an HTTPError wrapping a ValidationError keeps the validation details but
+// answers with the status of the HTTPError. Without a StatusCoder the status
+// follows the classification of the chain. Unknown errors become a 500
+// without details.
+func ProblemOf(err error) Problem {
+	problem := Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(http.StatusInternalServerError),
+		Status: http.StatusInternalServerError,
+	}
+
+	var detailer ProblemDetailer
+	if stderrors.As(err, &detailer) {
+		problem = detailer.ProblemDetails()
+	}
+
+	var coder StatusCoder
+	if stderrors.As(err, &coder) && coder.HTTPStatus() != 0 {
+		problem.Status = coder.HTTPStatus()
+	} else if err != nil {
+		problem.Status = StatusForClassification(Classify(err))
+	}
+	if problem.Type == "about:blank" {
+		problem.Title = http.StatusText(problem.Status)
+	}
+	if code := CodeOf(err); code != "" {
+		extensions := map[string]interface{}{"code": code}
+		for name, value := range problem.Extensions {
+			extensions[name] = value
+		}
+		problem.Extensions = extensions
+	}
+	return problem
+}
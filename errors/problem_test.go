@@ -0,0 +1,122 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestProblemOf(t *testing.T) {
+	validation := NewAggregateValidationError("configuration", "validation failed", []ValidationError{
+		{Field: "Name", Message: "field is required"},
+		{Field: "Port", Message: "value must be at least 1"},
+	})
+
+	tests := []struct {
+		name string
+		err  error
+		want Problem
+	}{
+		{
+			name: "http error",
+			err:  WrapHTTPError(nil, http.StatusNotFound, "user 42 not found"),
+			want: Problem{Type: "about:blank", Title: "Not Found", Status: 404, Detail: "user 42 not found", Extensions: map[string]interface{}{"code": "HTTP-404"}},
+		},
+		{
+			name: "validation error",
+			err:  validation,
+			want: Problem{
+				Type:   "urn:mantyboot:problem:validation",
+				Title:  "Validation Failed",
+				Status: 400,
+				Detail: validation.Error(),
+				Extensions: map[string]interface{}{"code": "VAL-001", "invalid-fields": []map[string]string{
+					{"field": "Name", "message": "field is required", "code": "VAL-001"},
+					{"field": "Port", "message": "value must be at least 1", "code": "VAL-001"},
+				}},
+			},
+		},
+		{
+			name: "database error hides cause",
+			err:  WrapDatabaseError(fmt.Errorf("Error 1146: Table 'shop.users' doesn't exist"), "query", "failed to fetch user"),
+			want: Problem{
+				Type:       "urn:mantyboot:problem:database",
+				Title:      "Database Error",
+				Status:     500,
+				Detail:     "failed to fetch user",
+				Extensions: map[string]interface{}{"code": "DB-001", "operation": "query"},
+			},
+		},
+		{
+			name: "configuration error",
+			err:  WrapConfigurationError(nil, "missing datasource"),
+			want: Problem{Type: "urn:mantyboot:problem:configuration", Title: "Configuration Error", Status: 500, Detail: "missing datasource", Extensions: map[string]interface{}{"code": "CFG-001"}},
+		},
+		{
+			name: "status from outer http error",
+			err:  WrapHTTPError(ValidationError{Field: "id", Message: "malformed"}, http.StatusUnprocessableEntity, "bad request body"),
+			want: Problem{Type: "about:blank", Title: "Unprocessable Entity", Status: 422, Detail: "bad request body", Extensions: map[string]interface{}{"code": "HTTP-422"}},
+		},
+		{
+			name: "details from inner error",
+			err:  fmt.Errorf("handler: %w", ValidationError{Field: "id", Message: "malformed"}),
+			want: Problem{
+				Type:   "urn:mantyboot:problem:validation",
+				Title:  "Validation Failed",
+				Status: 400,
+				Detail: "validation error for field 'id': malformed",
+				Extensions: map[string]interface{}{"code": "VAL-001", "invalid-fields": []map[string]string{
+					{"field": "id", "message": "malformed", "code": "VAL-001"},
+				}},
+			},
+		},
+		{
+			name: "unknown error",
+			err:  fmt.Errorf("boom"),
+			want: Problem{Type: "about:blank", Title: "Internal Server Error", Status: 500},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ProblemOf(tt.err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProblemOf() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteProblem(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/users?dry-run=true", nil)
+
+	WriteProblem(recorder, request, ValidationError{Field: "email", Message: "field is required"})
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", recorder.Code)
+	}
+	if got := recorder.Header().Get("Content-Type"); got != "application/problem+json" {
+		t.Errorf("content type = %q", got)
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	want := map[string]interface{}{
+		"type":     "urn:mantyboot:problem:validation",
+		"title":    "Validation Failed",
+		"status":   float64(400),
+		"detail":   "validation error for field 'email': field is required",
+		"instance": "/users",
+		"code":     "VAL-001",
+		"invalid-fields": []interface{}{
+			map[string]interface{}{"field": "email", "message": "field is required", "code": "VAL-001"},
+		},
+	}
+	if !reflect.DeepEqual(body, want) {
+		t.Errorf("body = %v, want %v", body, want)
+	}
+}
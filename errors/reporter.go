@@ -0,0 +1,297 @@
+package errors
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	mathrand "math/rand"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// fingerprintFrames is the number of innermost frames that identify an error.
+const fingerprintFrames = 3
+
+// Reporter sends error events to an error tracker.
+type Reporter interface {
+	Report(ctx context.Context, event Event) error
+}
+
+// Event describes a reported error.
+type Event struct {
+	ID          string                 `json:"event_id"`
+	Timestamp   time.Time              `json:"timestamp"`
+	Level       string                 `json:"level"`
+	Message     string                 `json:"message"`
+	Type        string                 `json:"type"`
+	Code        string                 `json:"code,omitempty"`
+	Fingerprint string                 `json:"fingerprint"`
+	Frames      []Frame                `json:"frames,omitempty"`
+	Attrs       map[string]interface{} `json:"attrs,omitempty"`
+	Request     *EventRequest          `json:"request,omitempty"`
+}
+
+type EventRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+// NewEvent describes err with the frames of the outermost StackTraceError in
+// its chain and the type of its root cause.
+func NewEvent(err error) Event {
+	event := Event{
+		ID:        newEventID(),
+		Timestamp: time.Now().UTC(),
+		Level:     "error",
+		Message:   err.Error(),
+		Type:      fmt.Sprintf("%T", rootCause(err)),
+		Code:      CodeOf(err),
+	}
+
+	inChain(err, func(err error) bool {
+		switch trace := err.(type) {
+		case StackTraceError:
+			event.Frames = trace.Frames()
+		case *StackTraceError:
+			event.Frames = trace.Frames()
+		default:
+			return false
+		}
+		return true
+	})
+
+	if attrs := Attrs(err); len(attrs) > 0 {
+		event.Attrs = make(map[string]interface{}, len(attrs))
+		for _, attr := range attrs {
+			event.Attrs[attr.Key] = attr.Value.Resolve().Any()
+		}
+	}
+	event.Fingerprint = fingerprint(event)
+	return event
+}
+
+// WithRequest records the method and URL of the request that failed. The
+// query is left out, because it may carry tokens or personal data.
+func (e Event) WithRequest(r *http.Request) Event {
+	if r != nil && r.URL != nil {
+		u := *r.URL
+		u.RawQuery, u.ForceQuery, u.Fragment, u.RawFragment, u.User = "", false, "", "", nil
+		e.Request = &EventRequest{Method: r.Method, URL: u.String()}
+	}
+	return e
+}
+
+func rootCause(err error) error {
+	for {
+		cause, ok := err.(interface{ Unwrap() error })
+		if !ok || cause.Unwrap() == nil {
+			return err
+		}
+		err = cause.Unwrap()
+	}
+}
+
+// fingerprint groups events by error type and innermost frames, or by message
+// when no stack was captured.
+func fingerprint(event Event) string {
+	hash := sha256.New()
+	io.WriteString(hash, event.Type+"\n"+event.Code+"\n")
+	if len(event.Frames) == 0 {
+		io.WriteString(hash, event.Message)
+	}
+	for i, frame := range event.Frames {
+		if i == fingerprintFrames {
+			break
+		}
+		io.WriteString(hash, frame.Function+"\n")
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+func newEventID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+type AsyncReporterOptions struct {
+	// QueueSize bounds the events waiting to be sent, 100 by default. Events
+	// reported while the queue is full are dropped.
+	QueueSize int
+	// SampleRate is the share of events sent, between 0 and 1. Zero sends all.
+	SampleRate float64
+	// DedupeWindow drops events whose fingerprint was reported within the window.
+	DedupeWindow time.Duration
+	// Timeout bounds every call to the wrapped reporter, 10 seconds by default.
+	Timeout time.Duration
+	Logger  *log.Logger
+}
+
+// AsyncReporter sends events to another reporter from a background goroutine
+// so that reporting never blocks a request.
+type AsyncReporter struct {
+	reporter Reporter
+	options  AsyncReporterOptions
+	queue    chan Event
+	done     chan struct{}
+	random   func() float64
+	now      func() time.Time
+
+	mu        sync.Mutex
+	closed    bool
+	lastSeen  map[string]time.Time
+	lastSweep time.Time
+	dropped   int
+}
+
+func NewAsyncReporter(reporter Reporter, options AsyncReporterOptions) *AsyncReporter {
+	if options.QueueSize <= 0 {
+		options.QueueSize = 100
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = 10 * time.Second
+	}
+	if options.Logger == nil {
+		options.Logger = log.New(os.Stderr, "[mantyboot]", log.LstdFlags)
+	}
+
+	r := &AsyncReporter{
+		reporter: reporter,
+		options:  options,
+		queue:    make(chan Event, options.QueueSize),
+		done:     make(chan struct{}),
+		random:   mathrand.Float64,
+		now:      time.Now,
+		lastSeen: make(map[string]time.Time),
+	}
+	go r.run()
+	return r
+}
+
+// Report queues event unless it is sampled out, a duplicate or the queue is full.
+func (r *AsyncReporter) Report(ctx context.Context, event Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return fmt.Errorf("reporter is closed")
+	}
+	if r.options.SampleRate > 0 && r.options.SampleRate < 1 && r.random() >= r.options.SampleRate {
+		return nil
+	}
+	var now time.Time
+	if r.options.DedupeWindow > 0 {
+		now = r.now()
+		r.sweep(now)
+		if last, ok := r.lastSeen[event.Fingerprint]; ok && now.Sub(last) < r.options.DedupeWindow {
+			return nil
+		}
+	}
+
+	select {
+	case r.queue <- event:
+		// A dropped event is not remembered, so that a repeat can still be reported.
+		if r.options.DedupeWindow > 0 {
+			r.lastSeen[event.Fingerprint] = now
+		}
+	default:
+		r.dropped++
+	}
+	return nil
+}
+
+// sweep forgets fingerprints last seen before the dedupe window, at most once
+// per window, so that distinct messages do not accumulate forever.
+func (r *AsyncReporter) sweep(now time.Time) {
+	if now.Sub(r.lastSweep) < r.options.DedupeWindow {
+		return
+	}
+	for fingerprint, last := range r.lastSeen {
+		if now.Sub(last) >= r.options.DedupeWindow {
+			delete(r.lastSeen, fingerprint)
+		}
+	}
+	r.lastSweep = now
+}
+
+// Dropped returns the number of events dropped because the queue was full.
+func (r *AsyncReporter) Dropped() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.dropped
+}
+
+func (r *AsyncReporter) run() {
+	defer close(r.done)
+	for event := range r.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), r.options.Timeout)
+		if err := r.reporter.Report(ctx, event); err != nil {
+			r.options.Logger.Printf("failed to report error event %s: %v", event.ID, err)
+		}
+		cancel()
+	}
+}
+
+// Close stops accepting events and waits until the queued events are sent
+// or ctx is done.
+func (r *AsyncReporter) Close(ctx context.Context) error {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.queue)
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// JSONLinesReporter writes every event as one line of JSON.
+type JSONLinesReporter struct {
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+func NewJSONLinesReporter(writer io.Writer) *JSONLinesReporter {
+	return &JSONLinesReporter{writer: writer}
+}
+
+// NewFileReporter appends events to the file at path, creating it if needed.
+func NewFileReporter(path string) (*JSONLinesReporter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return NewJSONLinesReporter(file), nil
+}
+
+func (r *JSONLinesReporter) Report(ctx context.Context, event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, err = r.writer.Write(append(line, '\n'))
+	return err
+}
+
+// Close closes the underlying writer if it is an io.Closer.
+func (r *JSONLinesReporter) Close() error {
+	if closer, ok := r.writer.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
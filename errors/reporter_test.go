@@ -0,0 +1,314 @@
+package errors
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingReporter struct {
+	mu      sync.Mutex
+	events  []Event
+	release chan struct{}
+}
+
+func (r *recordingReporter) Report(ctx context.Context, event Event) error {
+	if r.release != nil {
+		<-r.release
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *recordingReporter) Events() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Event(nil), r.events...)
+}
+
+func failOrder(id int) error {
+	return WithStackTrace(With(fmt.Errorf("order %d failed", id), "order_id", id), "checkout")
+}
+
+func TestNewEvent(t *testing.T) {
+	event := NewEvent(WrapDatabaseError(failOrder(1), "insert", "failed to save order"))
+
+	if event.Type != "*errors.errorString" {
+		t.Errorf("Type = %q, want the type of the root cause", event.Type)
+	}
+	if event.Code != CodeDatabase || event.Level != "error" || len(event.ID) != 32 {
+		t.Errorf("unexpected event %+v", event)
+	}
+	if len(event.Frames) == 0 || event.Frames[0].Function != "github.com/zbum/mantyboot/errors.failOrder" {
+		t.Errorf("Frames = %v, want the frames of the stack trace", event.Frames)
+	}
+	if event.Attrs["order_id"] != int64(1) {
+		t.Errorf("Attrs = %v", event.Attrs)
+	}
+
+	// Same type and frames share a fingerprint even with different messages.
+	other := NewEvent(WrapDatabaseError(failOrder(2), "insert", "failed to save order"))
+	if event.Fingerprint != other.Fingerprint {
+		t.Errorf("fingerprints differ: %s, %s", event.Fingerprint, other.Fingerprint)
+	}
+	if plain := NewEvent(fmt.Errorf("order 1 failed")); plain.Fingerprint == event.Fingerprint {
+		t.Error("errors without frames share the fingerprint of a stack trace")
+	}
+}
+
+func TestAsyncReporter_Dedupe(t *testing.T) {
+	recorder := &recordingReporter{}
+	reporter := NewAsyncReporter(recorder, AsyncReporterOptions{DedupeWindow: time.Minute})
+
+	for i := 0; i < 3; i++ {
+		reporter.Report(context.Background(), NewEvent(failOrder(i)))
+	}
+	reporter.Report(context.Background(), NewEvent(fmt.Errorf("other")))
+	if err := reporter.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if got := len(recorder.Events()); got != 2 {
+		t.Errorf("reported %d events, want 2", got)
+	}
+	if err := reporter.Report(context.Background(), NewEvent(fmt.Errorf("late"))); err == nil {
+		t.Error("Report() after Close() succeeded")
+	}
+}
+
+func TestAsyncReporter_DedupeExpires(t *testing.T) {
+	recorder := &recordingReporter{}
+	reporter := NewAsyncReporter(recorder, AsyncReporterOptions{DedupeWindow: time.Minute})
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	reporter.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		reporter.Report(context.Background(), NewEvent(fmt.Errorf("order %d failed", i)))
+	}
+	now = now.Add(time.Minute)
+	reporter.Report(context.Background(), NewEvent(fmt.Errorf("order 0 failed")))
+
+	reporter.mu.Lock()
+	seen := len(reporter.lastSeen)
+	reporter.mu.Unlock()
+	if seen != 1 {
+		t.Errorf("remembered %d fingerprints, want only the one seen within the window", seen)
+	}
+
+	reporter.Close(context.Background())
+	if got := len(recorder.Events()); got != 4 {
+		t.Errorf("reported %d events, want 4", got)
+	}
+}
+
+func TestAsyncReporter_Sampling(t *testing.T) {
+	recorder := &recordingReporter{}
+	reporter := NewAsyncReporter(recorder, AsyncReporterOptions{SampleRate: 0.5})
+	samples := []float64{0.1, 0.7, 0.4, 0.9}
+	reporter.random = func() float64 {
+		sample := samples[0]
+		samples = samples[1:]
+		return sample
+	}
+
+	for i := 0; i < 4; i++ {
+		reporter.Report(context.Background(), NewEvent(fmt.Errorf("error %d", i)))
+	}
+	reporter.Close(context.Background())
+
+	var messages []string
+	for _, event := range recorder.Events() {
+		messages = append(messages, event.Message)
+	}
+	if got := strings.Join(messages, ","); got != "error 0,error 2" {
+		t.Errorf("reported %s, want error 0,error 2", got)
+	}
+}
+
+func TestAsyncReporter_QueueFull(t *testing.T) {
+	recorder := &recordingReporter{release: make(chan struct{})}
+	reporter := NewAsyncReporter(recorder, AsyncReporterOptions{QueueSize: 1})
+
+	// The first event is taken by the worker, the second waits in the queue.
+	reporter.Report(context.Background(), NewEvent(fmt.Errorf("first")))
+	deadline := time.Now().Add(time.Second)
+	for len(reporter.queue) != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	reporter.Report(context.Background(), NewEvent(fmt.Errorf("second")))
+	reporter.Report(context.Background(), NewEvent(fmt.Errorf("third")))
+
+	if got := reporter.Dropped(); got != 1 {
+		t.Errorf("Dropped() = %d, want 1", got)
+	}
+	close(recorder.release)
+	reporter.Close(context.Background())
+	if got := len(recorder.Events()); got != 2 {
+		t.Errorf("reported %d events, want 2", got)
+	}
+}
+
+func TestAsyncReporter_DroppedEventsAreNotDeduped(t *testing.T) {
+	recorder := &recordingReporter{release: make(chan struct{})}
+	reporter := NewAsyncReporter(recorder, AsyncReporterOptions{QueueSize: 1, DedupeWindow: time.Minute})
+	waitForEmptyQueue := func() {
+		deadline := time.Now().Add(time.Second)
+		for len(reporter.queue) != 0 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	reporter.Report(context.Background(), NewEvent(fmt.Errorf("first")))
+	waitForEmptyQueue()
+	reporter.Report(context.Background(), NewEvent(fmt.Errorf("second")))
+	reporter.Report(context.Background(), NewEvent(fmt.Errorf("third")))
+	if got := reporter.Dropped(); got != 1 {
+		t.Fatalf("Dropped() = %d, want 1", got)
+	}
+
+	// Let the worker finish the first event and take the second.
+	recorder.release <- struct{}{}
+	waitForEmptyQueue()
+	reporter.Report(context.Background(), NewEvent(fmt.Errorf("third")))
+
+	close(recorder.release)
+	reporter.Close(context.Background())
+	var messages []string
+	for _, event := range recorder.Events() {
+		messages = append(messages, event.Message)
+	}
+	if got := strings.Join(messages, ","); got != "first,second,third" {
+		t.Errorf("reported %s, want first,second,third", got)
+	}
+}
+
+func TestFileReporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "errors.jsonl")
+	reporter, err := NewFileReporter(path)
+	if err != nil {
+		t.Fatalf("NewFileReporter() error = %v", err)
+	}
+	reporter.Report(context.Background(), NewEvent(failOrder(1)))
+	reporter.Report(context.Background(), NewEvent(WrapHTTPError(nil, 503, "unavailable")).WithRequest(httptest.NewRequest(http.MethodGet, "/orders?page=2&token=secret", nil)))
+	if err := reporter.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		events = append(events, event)
+	}
+	if len(events) != 2 {
+		t.Fatalf("read %d events, want 2", len(events))
+	}
+	if events[0].Frames[0].Function != "github.com/zbum/mantyboot/errors.failOrder" {
+		t.Errorf("frames = %v", events[0].Frames)
+	}
+	if events[1].Code != "HTTP-503" || events[1].Request == nil || events[1].Request.URL != "/orders" {
+		t.Errorf("event = %+v", events[1])
+	}
+}
+
+func TestSentryReporter(t *testing.T) {
+	var (
+		path, auth, contentType string
+		body                    []byte
+	)
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, auth, contentType = r.URL.Path, r.Header.Get("X-Sentry-Auth"), r.Header.Get("Content-Type")
+		body, _ = io.ReadAll(r.Body)
+		w.Write([]byte(`{"id":"ok"}`))
+	}))
+	defer stub.Close()
+
+	dsn := strings.Replace(stub.URL, "http://", "http://public-key@", 1) + "/42"
+	reporter, err := NewSentryReporter(dsn, stub.Client())
+	if err != nil {
+		t.Fatalf("NewSentryReporter() error = %v", err)
+	}
+
+	event := NewEvent(With(failOrder(7), "user_id", "u-1"))
+	if err := reporter.Report(context.Background(), event); err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+
+	if path != "/api/42/envelope/" {
+		t.Errorf("path = %q", path)
+	}
+	if contentType != "application/x-sentry-envelope" || !strings.Contains(auth, "sentry_key=public-key") {
+		t.Errorf("content type = %q, auth = %q", contentType, auth)
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(body), []byte("\n"))
+	if len(lines) != 3 {
+		t.Fatalf("envelope has %d lines, want 3:\n%s", len(lines), body)
+	}
+	var header, item map[string]interface{}
+	var payload sentryEvent
+	for i, target := range []interface{}{&header, &item, &payload} {
+		if err := json.Unmarshal(lines[i], target); err != nil {
+			t.Fatalf("line %d is not JSON: %v", i, err)
+		}
+	}
+	if header["event_id"] != event.ID || item["type"] != "event" || int(item["length"].(float64)) != len(lines[2]) {
+		t.Errorf("header = %v, item = %v", header, item)
+	}
+	if payload.Fingerprint[0] != event.Fingerprint || payload.Extra["user_id"] != "u-1" {
+		t.Errorf("payload = %+v", payload)
+	}
+	exception := payload.Exception.Values[0]
+	frames := exception.Stacktrace.Frames
+	if exception.Type != "*errors.errorString" || frames[len(frames)-1].Function != "github.com/zbum/mantyboot/errors.failOrder" {
+		t.Errorf("exception = %+v", exception)
+	}
+}
+
+func TestSentryReporter_InvalidDSN(t *testing.T) {
+	for _, dsn := range []string{"", "https://sentry.example.com/1", "https://key@sentry.example.com"} {
+		if _, err := NewSentryReporter(dsn, nil); err == nil {
+			t.Errorf("NewSentryReporter(%q) succeeded", dsn)
+		}
+	}
+}
+
+func TestErrorMapper_ReportsServerErrors(t *testing.T) {
+	recorder := &recordingReporter{}
+	mapper := NewErrorMapper()
+	mapper.SetReporter(recorder)
+
+	for _, err := range []error{
+		WrapHTTPError(nil, http.StatusNotFound, "missing"),
+		WrapDatabaseError(fmt.Errorf("connection refused"), "query", "failed"),
+	} {
+		mapper.WriteProblem(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil), err)
+	}
+
+	events := recorder.Events()
+	if len(events) != 1 || events[0].Code != CodeDatabase || events[0].Request.URL != "/orders" {
+		t.Errorf("events = %+v, want only the database error", events)
+	}
+}
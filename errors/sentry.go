@@ -0,0 +1,158 @@
+package errors
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+const sentryClient = "mantyboot/1.0"
+
+// SentryReporter sends events as envelopes to a Sentry compatible endpoint.
+type SentryReporter struct {
+	dsn       string
+	publicKey string
+	endpoint  string
+	client    *http.Client
+}
+
+// NewSentryReporter parses a DSN of the form
+// https://<public key>@<host>[/<path>]/<project id>. A nil client uses
+// http.DefaultClient.
+func NewSentryReporter(dsn string, client *http.Client) (*SentryReporter, error) {
+	parsed, err := url.Parse(dsn)
+	if err != nil {
+		return nil, WrapConfigurationError(err, "invalid Sentry DSN")
+	}
+	projectID := path.Base(parsed.Path)
+	if parsed.User == nil || parsed.User.Username() == "" || parsed.Host == "" || projectID == "." || projectID == "/" {
+		return nil, WrapConfigurationError(nil, fmt.Sprintf("invalid Sentry DSN %q", dsn))
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	endpoint := url.URL{
+		Scheme: parsed.Scheme,
+		Host:   parsed.Host,
+		Path:   path.Join("/", path.Dir(parsed.Path), "api", projectID, "envelope") + "/",
+	}
+	return &SentryReporter{
+		dsn:       dsn,
+		publicKey: parsed.User.Username(),
+		endpoint:  endpoint.String(),
+		client:    client,
+	}, nil
+}
+
+func (r *SentryReporter) Report(ctx context.Context, event Event) error {
+	envelope, err := r.envelope(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(envelope))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_key=%s, sentry_client=%s", r.publicKey, sentryClient))
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return WrapHTTPError(nil, resp.StatusCode, "unexpected Sentry response")
+	}
+	return nil
+}
+
+type sentryFrame struct {
+	Function string `json:"function"`
+	AbsPath  string `json:"abs_path"`
+	Filename string `json:"filename"`
+	Lineno   int    `json:"lineno"`
+}
+
+type sentryStacktrace struct {
+	Frames []sentryFrame `json:"frames"`
+}
+
+type sentryException struct {
+	Type       string            `json:"type"`
+	Value      string            `json:"value"`
+	Stacktrace *sentryStacktrace `json:"stacktrace,omitempty"`
+}
+
+type sentryEvent struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   string                 `json:"timestamp"`
+	Level       string                 `json:"level"`
+	Platform    string                 `json:"platform"`
+	Fingerprint []string               `json:"fingerprint"`
+	Tags        map[string]string      `json:"tags,omitempty"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+	Request     *EventRequest          `json:"request,omitempty"`
+	Exception   struct {
+		Values []sentryException `json:"values"`
+	} `json:"exception"`
+}
+
+// envelope encodes the envelope header, the item header and the event, one per line.
+func (r *SentryReporter) envelope(event Event) ([]byte, error) {
+	payload := sentryEvent{
+		EventID:     event.ID,
+		Timestamp:   event.Timestamp.UTC().Format(time.RFC3339Nano),
+		Level:       event.Level,
+		Platform:    "go",
+		Fingerprint: []string{event.Fingerprint},
+		Extra:       event.Attrs,
+		Request:     event.Request,
+	}
+	if event.Code != "" {
+		payload.Tags = map[string]string{"code": event.Code}
+	}
+
+	exception := sentryException{Type: event.Type, Value: event.Message}
+	if len(event.Frames) > 0 {
+		exception.Stacktrace = &sentryStacktrace{}
+		// Sentry lists frames from the outermost call to the innermost.
+		for i := len(event.Frames) - 1; i >= 0; i-- {
+			frame := event.Frames[i]
+			exception.Stacktrace.Frames = append(exception.Stacktrace.Frames, sentryFrame{
+				Function: frame.Function,
+				AbsPath:  frame.File,
+				Filename: path.Base(frame.File),
+				Lineno:   frame.Line,
+			})
+		}
+	}
+	payload.Exception.Values = []sentryException{exception}
+
+	item, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	header, err := json.Marshal(map[string]string{
+		"event_id": event.ID,
+		"sent_at":  time.Now().UTC().Format(time.RFC3339Nano),
+		"dsn":      r.dsn,
+	})
+	if err != nil {
+		return nil, err
+	}
+	itemHeader := fmt.Sprintf(`{"type":"event","length":%d}`, len(item))
+
+	return []byte(strings.Join([]string{string(header), itemHeader, string(item)}, "\n") + "\n"), nil
+}
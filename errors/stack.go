@@ -0,0 +1,159 @@
+package errors
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+)
+
+// DefaultTrimmedFrames are the function prefixes Trim removes when called
+// without arguments.
+var DefaultTrimmedFrames = []string{"runtime.", "net/http."}
+
+// Stack trace utilities
+type StackTraceError struct {
+	Message string
+	Cause   error
+	Stack   []uintptr
+
+	trimmed []string
+}
+
+// Frame is a resolved entry of a captured stack.
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+func (f Frame) String() string {
+	return fmt.Sprintf("%s\n\t%s:%d", f.Function, f.File, f.Line)
+}
+
+func (e StackTraceError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s (caused by: %v)", e.Message, e.Cause)
+	}
+	return e.Message
+}
+
+func (e StackTraceError) Unwrap() error {
+	return e.Cause
+}
+
+// Frames resolves the captured stack, innermost call first.
+func (e StackTraceError) Frames() []Frame {
+	if len(e.Stack) == 0 {
+		return nil
+	}
+
+	var frames []Frame
+	callers := runtime.CallersFrames(e.Stack)
+	for {
+		frame, more := callers.Next()
+		if !e.isTrimmed(frame.Function) {
+			frames = append(frames, Frame{Function: frame.Function, File: frame.File, Line: frame.Line})
+		}
+		if !more {
+			break
+		}
+	}
+	return frames
+}
+
+func (e StackTraceError) isTrimmed(function string) bool {
+	for _, prefix := range e.trimmed {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Trim returns a copy whose frames leave out functions starting with one of
+// the prefixes, or with DefaultTrimmedFrames when none are given.
+func (e StackTraceError) Trim(prefixes ...string) StackTraceError {
+	if len(prefixes) == 0 {
+		prefixes = DefaultTrimmedFrames
+	}
+	e.trimmed = append(append([]string(nil), e.trimmed...), prefixes...)
+	return e
+}
+
+// Format prints the message for %s and %v. %+v prints every error of the
+// chain on its own line, followed by the frames of stack trace errors.
+func (e StackTraceError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			writeChain(s, e)
+			return
+		}
+		io.WriteString(s, e.Error())
+	case 's':
+		io.WriteString(s, e.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", e.Error())
+	}
+}
+
+func writeChain(w io.Writer, err error) {
+	for i := 0; err != nil; i++ {
+		if i > 0 {
+			io.WriteString(w, "\ncaused by: ")
+		}
+
+		var trace StackTraceError
+		switch typed := err.(type) {
+		case StackTraceError:
+			trace = typed
+		case *StackTraceError:
+			trace = *typed
+		default:
+			io.WriteString(w, err.Error())
+			err = stderrors.Unwrap(err)
+			continue
+		}
+
+		io.WriteString(w, trace.Message)
+		for _, frame := range trace.Frames() {
+			fmt.Fprintf(w, "\n\t%s", strings.ReplaceAll(frame.String(), "\n", "\n\t"))
+		}
+		err = trace.Cause
+	}
+}
+
+func (e StackTraceError) MarshalJSON() ([]byte, error) {
+	value := struct {
+		Message string      `json:"message"`
+		Cause   interface{} `json:"cause,omitempty"`
+		Stack   []Frame     `json:"stack,omitempty"`
+	}{
+		Message: e.Message,
+		Stack:   e.Frames(),
+	}
+
+	if e.Cause != nil {
+		if marshaler, ok := e.Cause.(json.Marshaler); ok {
+			value.Cause = marshaler
+		} else {
+			value.Cause = e.Cause.Error()
+		}
+	}
+	return json.Marshal(value)
+}
+
+// WithStackTrace captures the stack of its caller.
+func WithStackTrace(err error, message string) error {
+	var stack [32]uintptr
+	n := runtime.Callers(2, stack[:])
+
+	return StackTraceError{
+		Message: message,
+		Cause:   err,
+		Stack:   stack[:n],
+	}
+}
@@ -0,0 +1,119 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func captureStackTrace(cause error) error {
+	return WithStackTrace(cause, "failed to load user")
+}
+
+func TestStackTraceError_Frames(t *testing.T) {
+	trace := captureStackTrace(nil).(StackTraceError)
+
+	frames := trace.Frames()
+	if len(frames) == 0 {
+		t.Fatal("no frames captured")
+	}
+	if got := frames[0].Function; got != "github.com/zbum/mantyboot/errors.captureStackTrace" {
+		t.Errorf("first frame = %q, want the caller of WithStackTrace", got)
+	}
+	if !strings.HasSuffix(frames[0].File, "stack_test.go") || frames[0].Line == 0 {
+		t.Errorf("first frame location = %s:%d", frames[0].File, frames[0].Line)
+	}
+}
+
+func TestStackTraceError_Trim(t *testing.T) {
+	var trace StackTraceError
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trace = captureStackTrace(nil).(StackTraceError)
+	})
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	hasPrefix := func(frames []Frame, prefix string) bool {
+		for _, frame := range frames {
+			if strings.HasPrefix(frame.Function, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if !hasPrefix(trace.Frames(), "net/http.") || !hasPrefix(trace.Frames(), "runtime.") {
+		t.Fatal("expected net/http and runtime frames before trimming")
+	}
+	trimmed := trace.Trim()
+	if hasPrefix(trimmed.Frames(), "net/http.") || hasPrefix(trimmed.Frames(), "runtime.") {
+		t.Errorf("trimmed frames still contain net/http or runtime frames: %v", trimmed.Frames())
+	}
+	if !hasPrefix(trimmed.Frames(), "github.com/zbum/mantyboot/errors.captureStackTrace") {
+		t.Errorf("trimmed frames lost application frames: %v", trimmed.Frames())
+	}
+	if len(trace.Frames()) == len(trimmed.Frames()) {
+		t.Error("Trim modified the original error")
+	}
+}
+
+func TestStackTraceError_Format(t *testing.T) {
+	inner := captureStackTrace(fmt.Errorf("connection refused"))
+	outer := WithStackTrace(fmt.Errorf("repository: %w", inner), "request failed")
+
+	if got, want := fmt.Sprintf("%v", inner), "failed to load user (caused by: connection refused)"; got != want {
+		t.Errorf("%%v = %q, want %q", got, want)
+	}
+
+	detailed := fmt.Sprintf("%+v", outer)
+	for _, fragment := range []string{
+		"request failed\n\tgithub.com/zbum/mantyboot/errors.TestStackTraceError_Format\n\t\t",
+		"\ncaused by: repository: failed to load user",
+		"\ncaused by: failed to load user\n\tgithub.com/zbum/mantyboot/errors.captureStackTrace\n\t\t",
+		"stack_test.go:",
+		"\ncaused by: connection refused",
+	} {
+		if !strings.Contains(detailed, fragment) {
+			t.Errorf("%%+v output does not contain %q:\n%s", fragment, detailed)
+		}
+	}
+}
+
+func TestStackTraceError_MarshalJSON(t *testing.T) {
+	outer := WithStackTrace(captureStackTrace(fmt.Errorf("connection refused")), "request failed").(StackTraceError).Trim()
+
+	data, err := json.Marshal(outer)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	var decoded struct {
+		Message string  `json:"message"`
+		Stack   []Frame `json:"stack"`
+		Cause   struct {
+			Message string  `json:"message"`
+			Cause   string  `json:"cause"`
+			Stack   []Frame `json:"stack"`
+		} `json:"cause"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if decoded.Message != "request failed" || decoded.Cause.Message != "failed to load user" || decoded.Cause.Cause != "connection refused" {
+		t.Errorf("unexpected messages: %s", data)
+	}
+	if len(decoded.Stack) == 0 || decoded.Stack[0].Function != "github.com/zbum/mantyboot/errors.TestStackTraceError_MarshalJSON" {
+		t.Errorf("unexpected stack: %v", decoded.Stack)
+	}
+	if len(decoded.Cause.Stack) == 0 || decoded.Cause.Stack[0].Function != "github.com/zbum/mantyboot/errors.captureStackTrace" {
+		t.Errorf("unexpected cause stack: %v", decoded.Cause.Stack)
+	}
+	for _, frame := range decoded.Stack {
+		if strings.HasPrefix(frame.Function, "runtime.") {
+			t.Errorf("trimmed stack contains %s", frame.Function)
+		}
+	}
+}
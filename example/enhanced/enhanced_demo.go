@@ -134,9 +134,9 @@ func registerRoutes(mux *mux.MantyMux, logger *log.Logger) {
 	})
 
 	// Error simulation endpoint
-	mux.HandleFunc("GET /api/error", func(w http.ResponseWriter, r *http.Request) {
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-	})
+	mux.HandleFunc("GET /api/error", errors.DefaultErrorMapper.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return errors.WrapDatabaseError(fmt.Errorf("connection refused"), "query", "failed to fetch users")
+	}))
 
 	// Panic simulation endpoint
 	mux.HandleFunc("GET /api/panic", func(w http.ResponseWriter, r *http.Request) {
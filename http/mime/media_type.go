@@ -12,6 +12,7 @@ const (
 	ContentTypeApplicationFormUrlencoded = "application/x-www-form-urlencoded"
 	ContentTypeApplicationOctetStream    = "application/octet-stream"
 	ContentTypeApplicationPdf            = "application/pdf"
+	ContentTypeApplicationProblemJson    = "application/problem+json"
 	ContentTypeApplicationRssXml         = "application/rss+xml"
 	ContentTypeApplicationNdjson         = "application/x-ndjson"
 	ContentTypeApplicationStreamJson     = "application/stream+json"
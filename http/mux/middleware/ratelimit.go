@@ -5,6 +5,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/zbum/mantyboot/errors"
 	"github.com/zbum/mantyboot/http/mux"
 )
 
@@ -57,7 +58,7 @@ func RateLimit(limiter *RateLimiter, keyFunc func(*http.Request) string) mux.Mid
 			key := keyFunc(r)
 
 			if !limiter.isAllowed(key) {
-				http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+				errors.WriteProblem(w, r, errors.HTTPError{StatusCode: http.StatusTooManyRequests, Message: "Rate limit exceeded"})
 				return
 			}
 
@@ -4,22 +4,44 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"runtime/debug"
 
+	"github.com/zbum/mantyboot/errors"
 	"github.com/zbum/mantyboot/http/mux"
 )
 
+// panicError captures the stack at the point of recovery, which still
+// contains the frames of the panicking handler, without runtime and
+// net/http frames.
+func panicError(value interface{}) errors.StackTraceError {
+	cause, ok := value.(error)
+	if !ok {
+		cause = fmt.Errorf("%v", value)
+	}
+	trace := errors.WithStackTrace(cause, "panic").(errors.StackTraceError)
+	return trace.Trim(append([]string{"github.com/zbum/mantyboot/http/mux/middleware.panicError"}, errors.DefaultTrimmedFrames...)...)
+}
+
+// Recovery answers panics through errors.DefaultErrorMapper, so a handler
+// panicking with an error is answered like one returning it. Other panic
+// values become a 500. Every panic is sent to the reporter of the mapper.
 func Recovery(logger *log.Logger) mux.Middleware {
+	return RecoveryWithErrorMapper(logger, errors.DefaultErrorMapper)
+}
+
+func RecoveryWithErrorMapper(logger *log.Logger, mapper *errors.ErrorMapper) mux.Middleware {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
 			defer func() {
 				if err := recover(); err != nil {
-					// Log the panic
-					logger.Println("panic recovered", fmt.Errorf("panic: %v", err))
-					logger.Println("stack trace: " + string(debug.Stack()))
+					// Log the panic with the stack of the panicking goroutine
+					recovered := panicError(err)
+					logger.Printf("panic recovered: %+v", recovered)
 
-					// Return 500 Internal Server Error
-					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+					// Server errors are reported by WriteProblem, other panics are reported here
+					if mapper.Status(recovered) < http.StatusInternalServerError {
+						mapper.Report(r, recovered)
+					}
+					mapper.WriteProblem(w, r, recovered)
 				}
 			}()
 
@@ -33,9 +55,8 @@ func RecoveryWithHandler(logger *log.Logger, handler func(http.ResponseWriter, *
 		return func(w http.ResponseWriter, r *http.Request) {
 			defer func() {
 				if err := recover(); err != nil {
-					// Log the panic
-					logger.Println("panic recovered", fmt.Errorf("panic: %v", err))
-					logger.Println("stack trace: " + string(debug.Stack()))
+					// Log the panic with the stack of the panicking goroutine
+					logger.Printf("panic recovered: %+v", panicError(err))
 
 					// Call custom handler
 					handler(w, r, err)